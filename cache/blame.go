@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"github.com/gaarutyunov/gitstat/types"
+	"time"
+)
+
+type (
+	// Blame is the blame of a file aggregated by committer and commit date.
+	Blame struct {
+		// Generated tells whether the contents mark the file as generated.
+		Generated bool      `json:"generated,omitempty"`
+		Ranges    []*Range  `json:"ranges"`
+		Stored    time.Time `json:"stored"`
+
+		index map[rangeKey]*Range
+	}
+
+	// Range is the number of lines of every kind last changed by a committer at a date.
+	// The date is zero if it is unknown.
+	Range struct {
+		Email string                   `json:"email"`
+		Login string                   `json:"login,omitempty"`
+		Date  time.Time                `json:"date"`
+		Lines map[types.LineKind]int64 `json:"lines"`
+	}
+
+	rangeKey struct {
+		email string
+		login string
+		date  int64
+	}
+)
+
+// Add counts n lines of kind last changed by the committer at date.
+func (b *Blame) Add(email, login string, date time.Time, kind types.LineKind, n int64) {
+	if b.index == nil {
+		b.index = make(map[rangeKey]*Range)
+	}
+
+	key := rangeKey{email: email, login: login, date: date.UnixNano()}
+
+	r, ok := b.index[key]
+	if !ok {
+		r = &Range{Email: email, Login: login, Date: date, Lines: make(map[types.LineKind]int64, 3)}
+		b.index[key] = r
+		b.Ranges = append(b.Ranges, r)
+	}
+
+	r.Lines[kind] += n
+}
+
+// Lines returns the number of lines of every kind in the file.
+func (b *Blame) Lines() map[types.LineKind]int64 {
+	res := make(map[types.LineKind]int64, 3)
+
+	for _, r := range b.Ranges {
+		for kind, n := range r.Lines {
+			res[kind] += n
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type (
+	// Cache stores aggregated blame of files on disk.
+	Cache struct {
+		db *bbolt.DB
+	}
+
+	// Key identifies the blame of a file by its path, blob and the last commit changing it in the history
+	// of the blamed ref. Later commits leave the lines of the file alone, so its blame at any ref is the blame
+	// at that commit and entries are reused across refs until the file changes. The blob alone isn't enough,
+	// a blob restored by a revert is blamed to the revert. The language is part of the key since lines are
+	// classified by its comment syntax. Keys without a commit are never cached.
+	Key struct {
+		Project  string
+		Commit   string
+		Path     string
+		Blob     string
+		Language string
+	}
+)
+
+// DefaultPath returns the cache file in the user cache directory, $XDG_CACHE_HOME/gitstat on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "gitstat", "blame.db"), nil
+}
+
+// Open opens or creates the cache file at path.
+func Open(path string) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		if errors.Is(err, bbolt.ErrTimeout) {
+			return nil, fmt.Errorf("cache %s is used by another process", path)
+		}
+		return nil, err
+	}
+
+	return &Cache{db: db}, nil
+}
+
+func (c *Cache) Close() error {
+	if c == nil {
+		return nil
+	}
+
+	return c.db.Close()
+}
+
+// Get returns the cached blame of the file, a nil cache never has it.
+func (c *Cache) Get(key Key) (*Blame, bool) {
+	if c == nil || key.Commit == "" {
+		return nil, false
+	}
+
+	var blame *Blame
+
+	err := c.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(key.Project))
+		if bucket == nil {
+			return nil
+		}
+
+		data := bucket.Get(key.bytes())
+		if data == nil {
+			return nil
+		}
+
+		blame = &Blame{}
+
+		return json.Unmarshal(data, blame)
+	})
+	if err != nil {
+		return nil, false
+	}
+
+	return blame, blame != nil
+}
+
+// Put stores the blame of the file, it is a no-op for a nil cache.
+func (c *Cache) Put(key Key, blame *Blame) error {
+	if c == nil || key.Commit == "" {
+		return nil
+	}
+
+	blame.Stored = time.Now()
+
+	data, err := json.Marshal(blame)
+	if err != nil {
+		return err
+	}
+
+	return c.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(key.Project))
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(key.bytes(), data)
+	})
+}
+
+// Prune removes entries stored before t and returns their number.
+func (c *Cache) Prune(t time.Time) (pruned int, err error) {
+	err = c.db.Update(func(tx *bbolt.Tx) error {
+		var empty [][]byte
+
+		err := tx.ForEach(func(name []byte, bucket *bbolt.Bucket) error {
+			var stale [][]byte
+
+			err := bucket.ForEach(func(k, v []byte) error {
+				var blame Blame
+
+				if err := json.Unmarshal(v, &blame); err != nil || blame.Stored.Before(t) {
+					stale = append(stale, k)
+				}
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, k := range stale {
+				if err := bucket.Delete(k); err != nil {
+					return err
+				}
+			}
+
+			pruned += len(stale)
+
+			if k, _ := bucket.Cursor().First(); k == nil {
+				empty = append(empty, name)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, name := range empty {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return
+}
+
+func (k Key) bytes() []byte {
+	return []byte(strings.Join([]string{k.Commit, k.Path, k.Blob, k.Language}, "\x00"))
+}
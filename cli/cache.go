@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/gaarutyunov/gitstat/cache"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sync"
+	"time"
+)
+
+// blameCache is shared by all statistics of the process since the cache file is locked while open.
+var blameCache struct {
+	once  sync.Once
+	cache *cache.Cache
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the blame cache",
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove blame cached before --older-than",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+
+		olderThan, err := flags.GetDuration("older-than")
+		if err != nil {
+			return err
+		}
+		path, err := getCachePath(flags)
+		if err != nil {
+			return err
+		}
+
+		c, err := cache.Open(path)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		pruned, err := c.Prune(time.Now().Add(-olderThan))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Pruned %d files from %s\n", pruned, path)
+
+		return nil
+	},
+}
+
+func getCachePath(flags *pflag.FlagSet) (string, error) {
+	path, err := flags.GetString("cache-path")
+	if err != nil || path != "" {
+		return path, err
+	}
+
+	return cache.DefaultPath()
+}
+
+// openCache opens the blame cache once, nil is returned if the cache is disabled or can't be opened.
+func openCache(flags *pflag.FlagSet) (*cache.Cache, error) {
+	noCache, err := flags.GetBool("no-cache")
+	if err != nil || noCache {
+		return nil, err
+	}
+	path, err := getCachePath(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	blameCache.once.Do(func() {
+		c, err := cache.Open(path)
+		if err != nil {
+			logrus.Warnf("blame cache is disabled: %v", err)
+			return
+		}
+
+		blameCache.cache = c
+	})
+
+	return blameCache.cache, nil
+}
+
+func closeCache() {
+	if err := blameCache.cache.Close(); err != nil {
+		logrus.Warnf("error closing blame cache: %v", err)
+	}
+}
+
+func init() {
+	cachePruneCmd.Flags().Duration("older-than", 30*24*time.Hour, "Remove blame cached before this duration, everything if 0")
+
+	cacheCmd.AddCommand(cachePruneCmd)
+	cmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// checkpointFile is the checkpoint of a run with projects completed by the run it resumes.
+type checkpointFile struct {
+	*models.Checkpoint
+	path      string
+	f         *os.File
+	completed *models.Baseline
+}
+
+// checkpointIgnored are flags which don't change statistics, so a run can be resumed with other values.
+var checkpointIgnored = []string{"resume", "checkpoint", "baseline", "format", "silent", "verbosity", "token", "retry", "rate", "concurrency-projects", "concurrency-files", "fail-on-errors"}
+
+// getCheckpointPath returns the checkpoint file, the default one in the user cache directory is named
+// by a hash of args so that runs with other servers, queries or periods don't overwrite each other's.
+func getCheckpointPath(flags *pflag.FlagSet, args []string) (string, error) {
+	path, err := flags.GetString("checkpoint")
+	if err != nil || path != "" {
+		return path, err
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(args, "\x00")))
+
+	return filepath.Join(dir, "gitstat", fmt.Sprintf("checkpoint-%x.jsonl", sum[:8])), nil
+}
+
+// checkpointArgs returns the flags a checkpoint can be resumed with.
+func checkpointArgs(flags *pflag.FlagSet) (args []string) {
+	flags.Visit(func(flag *pflag.Flag) {
+		if !slices.Contains(checkpointIgnored, flag.Name) {
+			args = append(args, fmt.Sprintf("--%s=%s", flag.Name, flag.Value))
+		}
+	})
+
+	return
+}
+
+// resumable reports whether a run can be resumed, restored projects have no commits,
+// merge requests or blame ranges, so runs counting them aren't.
+func resumable(flags *pflag.FlagSet, facts bool) (bool, error) {
+	if facts {
+		return false, nil
+	}
+
+	for _, name := range []string{"commits", "merge-requests"} {
+		v, err := flags.GetBool(name)
+		if err != nil || v {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// openCheckpoint creates the checkpoint of the run, with --resume projects completed
+// by the interrupted run are read from it first and written again.
+// Runs which can't be resumed get an empty checkpoint writing nothing.
+func openCheckpoint(flags *pflag.FlagSet, facts bool) (*checkpointFile, error) {
+	resume, err := flags.GetBool("resume")
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := resumable(flags, facts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		if resume {
+			return nil, errors.New("--resume can't be used with --commits, --merge-requests or --format sqlite")
+		}
+
+		return &checkpointFile{}, nil
+	}
+
+	args := checkpointArgs(flags)
+
+	path, err := getCheckpointPath(flags, args)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checkpointFile{path: path}
+
+	if resume {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, errors.Join(errors.New("no checkpoint to resume"), err)
+		}
+
+		c.completed, err = models.LoadCheckpoint(f, args)
+		_ = f.Close()
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("error reading checkpoint %s", path), err)
+		}
+
+		logrus.Infof("resuming with %d completed projects", len(c.completed.Projects))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	c.f, err = os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Checkpoint, err = models.NewCheckpoint(c.f, args, c.completed)
+	if err != nil {
+		_ = c.f.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Resumable reports whether the interrupted run can be continued with --resume,
+// which needs completed projects in the checkpoint.
+func (c *checkpointFile) Resumable() bool {
+	return c.Checkpoint.Len() != 0
+}
+
+// Close closes the checkpoint removing it if the run is done.
+func (c *checkpointFile) Close(done bool) {
+	if c.f == nil {
+		return
+	}
+
+	if err := c.f.Close(); err != nil {
+		logrus.Warnf("error closing checkpoint: %v", err)
+	}
+
+	if !done {
+		return
+	}
+
+	if err := os.Remove(c.path); err != nil {
+		logrus.Warnf("error removing checkpoint: %v", err)
+	}
+}
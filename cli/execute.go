@@ -2,7 +2,6 @@ package cli
 
 import (
 	"context"
-	"errors"
 	"os"
 	"os/signal"
 	"syscall"
@@ -12,10 +11,7 @@ func Execute(ctx context.Context) error {
 	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 	defer cancel()
 
-	err := cmd.ExecuteContext(ctx)
-	if err != nil && errors.Is(err, context.Canceled) {
-		return nil
-	}
+	defer closeCache()
 
-	return err
+	return cmd.ExecuteContext(ctx)
 }
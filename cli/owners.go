@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/owners"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/spf13/cobra"
+)
+
+var ownersCmd = &cobra.Command{
+	Use:   "owners",
+	Short: "Top contributors of every directory and suggested CODEOWNERS",
+	Long: `Aggregates blamed code lines per directory of every project and lists the users who last changed most of them.
+With --codeowners a CODEOWNERS file assigning directories to their top contributors is suggested,
+with --diff it is compared to the CODEOWNERS file of the repository.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+
+		depth, err := flags.GetInt("depth")
+		if err != nil {
+			return err
+		}
+		top, err := flags.GetInt("top")
+		if err != nil {
+			return err
+		}
+		files, err := flags.GetBool("files")
+		if err != nil {
+			return err
+		}
+		minShare, err := flags.GetFloat64("min-share")
+		if err != nil {
+			return err
+		}
+		codeowners, err := flags.GetBool("codeowners")
+		if err != nil {
+			return err
+		}
+		diff, err := flags.GetBool("diff")
+		if err != nil {
+			return err
+		}
+
+		g, err := newStats(cmd, withOwners())
+		if err != nil {
+			return err
+		}
+
+		o, ok := g.(types.OwnerStats)
+		if !ok {
+			return errors.New("ownership is not supported by the Git server")
+		}
+
+		perProject := o.Owners()
+
+		if err := g.Err(); err != nil {
+			return err
+		}
+
+		reports := make(owners.Reports, len(perProject))
+
+		for project, counter := range perProject {
+			paths := owners.Aggregate(counter.PerFile(), depth, files)
+			suggested := owners.Suggest(paths, top, minShare)
+			report := &owners.Report{}
+
+			if codeowners {
+				report.Codeowners = suggested
+			}
+
+			if diff {
+				var content []byte
+
+				report.Existing, content = counter.Codeowners()
+				report.Changes = owners.Parse(content).Diff(suggested)
+
+				if report.Changes == nil {
+					report.Changes = []owners.Change{}
+				}
+			}
+
+			for _, p := range paths {
+				p.Owners = p.Top(top)
+				report.Paths = append(report.Paths, p)
+			}
+
+			reports[project] = report
+		}
+
+		format, err := flags.GetString("format")
+		if err != nil {
+			return err
+		}
+
+		switch types.Format(format) {
+		case types.Json:
+			data, err := json.Marshal(reports)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(data))
+		case types.Txt:
+			fmt.Print(reports.String())
+		default:
+			return fmt.Errorf("unknown format: %s", format)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	flags := ownersCmd.Flags()
+
+	flags.Int("depth", 2, "Depth of directories to report below the repository root")
+	flags.Int("top", 3, "Number of top contributors of every path")
+	flags.Bool("files", false, "Report files in addition to directories")
+	flags.Float64("min-share", 0.2, "Minimal share of lines of a directory for a contributor to own it in the suggested CODEOWNERS")
+	flags.Bool("codeowners", false, "Suggest a CODEOWNERS file for every project")
+	flags.Bool("diff", false, "Compare the suggested CODEOWNERS file to the existing one of every project")
+
+	cmd.AddCommand(ownersCmd)
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/report"
+	"github.com/gaarutyunov/gitstat/sqlite"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/spf13/pflag"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// newRun returns the metadata of the run started at started and finished now.
+func newRun(flags *pflag.FlagSet, started time.Time) (run report.Run, err error) {
+	run.Started = started
+	run.Finished = time.Now()
+	run.Duration = run.Finished.Sub(started)
+
+	if run.Server, err = flags.GetString("server"); err != nil {
+		return
+	}
+
+	if run.Host, err = flags.GetString("host"); err != nil {
+		return
+	}
+
+	if run.Query, err = flags.GetString("query"); err != nil {
+		return
+	}
+
+	if run.Since, err = getTime(flags, "since"); err != nil {
+		return
+	}
+
+	run.Until, err = getTime(flags, "until")
+
+	return
+}
+
+// writeStats writes stats computed by run in format, tmpl is the template file of the template format.
+func writeStats(w io.Writer, format types.Format, stats *models.Stats, run report.Run, tmpl string) error {
+	switch format {
+	case types.Json:
+		data, err := json.Marshal(stats)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(w, string(data))
+
+		return err
+	case types.Csv:
+		return stats.WriteCSV(w, ',')
+	case types.Tsv:
+		return stats.WriteCSV(w, '\t')
+	case types.Markdown:
+		return report.New(stats, run).WriteMarkdown(w)
+	case types.Html:
+		return report.New(stats, run).WriteHTML(w)
+	case types.Template:
+		return report.New(stats, run).WriteTemplate(w, tmpl)
+	case types.OpenMetrics:
+		return stats.WriteOpenMetrics(w, run.Duration)
+	case types.Txt:
+		_, err := fmt.Fprintln(w, stats.String())
+
+		return err
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+// writeDatabase replaces the SQLite database at path with facts by renaming a temporary database created next to it.
+func writeDatabase(ctx context.Context, path string, facts []types.ProjectFacts) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if err := errors.Join(f.Close(), sqlite.Write(ctx, f.Name(), facts)); err != nil {
+		return errors.Join(err, os.Remove(f.Name()))
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return errors.Join(err, os.Remove(f.Name()))
+	}
+
+	return nil
+}
+
+// writeFile replaces the file at path with the output of write by renaming a temporary file written next to it,
+// so readers like the node_exporter textfile collector never see a partial file.
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if err := errors.Join(write(f), f.Chmod(0o644), f.Close()); err != nil {
+		return errors.Join(err, os.Remove(f.Name()))
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return errors.Join(err, os.Remove(f.Name()))
+	}
+
+	return nil
+}
@@ -1,113 +1,135 @@
 package cli
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"github.com/gaarutyunov/gitstat/gitlab"
 	"github.com/gaarutyunov/gitstat/models"
 	"github.com/gaarutyunov/gitstat/types"
-	"github.com/gaarutyunov/gitstat/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"io"
 	"net/url"
+	"os"
+	"time"
 )
 
 var cmd = &cobra.Command{
 	Use: "gitstat",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		pFlags := cmd.PersistentFlags()
+		var b *models.Baseline
 
-		server, err := pFlags.GetString("server")
+		started := time.Now()
+
+		format, err := cmd.Flags().GetString("format")
 		if err != nil {
 			return err
 		}
-		host, err := pFlags.GetString("host")
+
+		tmpl, err := cmd.Flags().GetString("template")
 		if err != nil {
 			return err
 		}
-		token, err := pFlags.GetString("token")
-		if err != nil {
-			return err
+
+		if types.Format(format) == types.Template && tmpl == "" {
+			return errors.New("--template is required with --format template")
 		}
-		rateLimit, err := pFlags.GetInt("rate")
+
+		output, err := cmd.Flags().GetString("output-file")
 		if err != nil {
 			return err
 		}
-		userAliases, err := pFlags.GetStringSlice("user")
-		if err != nil {
-			return err
+
+		facts := types.Format(format) == types.Sqlite
+
+		if facts && output == "" {
+			return errors.New("--output-file is required with --format sqlite")
 		}
-		langExtensions, err := pFlags.GetStringSlice("lang")
+
+		baseline, err := cmd.Flags().GetString("baseline")
 		if err != nil {
 			return err
 		}
-		silent, err := pFlags.GetBool("silent")
+
+		if baseline != "" {
+			b, err = loadBaseline(baseline)
+			if err != nil {
+				return err
+			}
+		}
+
+		checkpoint, err := openCheckpoint(cmd.Flags(), facts)
 		if err != nil {
 			return err
 		}
 
-		query, _ := pFlags.GetString("query")
-
-		retries, err := pFlags.GetInt("retry")
+		g, err := newStats(cmd, withBaseline(b.Merge(checkpoint.completed)), withCheckpoint(checkpoint.Checkpoint), withFacts(facts))
 		if err != nil {
+			checkpoint.Close(false)
 			return err
 		}
 
-		users := make(utils.AliasMap[types.User])
+		cmd.SilenceUsage = true
+
+		stats := models.NewStats(g)
+
+		if err := g.Err(); err != nil {
+			checkpoint.Close(false)
+
+			if errors.Is(err, context.Canceled) {
+				if checkpoint.Resumable() {
+					return fmt.Errorf("interrupted, completed projects are saved to %s, continue with --resume: %w", checkpoint.path, err)
+				}
+
+				return fmt.Errorf("interrupted: %w", err)
+			}
 
-		err = users.Parse(userAliases)
-		if err != nil {
 			return err
 		}
 
-		extensions := make(utils.AliasMap[types.Language])
+		checkpoint.Close(true)
 
-		err = extensions.Parse(langExtensions)
+		run, err := newRun(cmd.Flags(), started)
 		if err != nil {
 			return err
 		}
 
-		var g types.Stats
-
-		switch types.GitServer(server) {
-		case types.Gitlab:
-			gitlab.SetRetries(retries)
-			g = gitlab.New(
-				host,
-				token,
-				gitlab.WithRateLimit(rateLimit),
-				gitlab.WithUsers(users.ToSlice(models.NewUser)...),
-				gitlab.WithLanguages(extensions.ToSlice(models.NewLanguage)...),
-				gitlab.WithQuery(query),
-				gitlab.WithContext(cmd.Context()),
-				gitlab.WithProgress(!silent),
-			)
+		write := func(w io.Writer) error {
+			return writeStats(w, types.Format(format), stats, run, tmpl)
 		}
 
-		stats := models.NewStats(g)
+		switch {
+		case facts:
+			err = writeDatabase(cmd.Context(), output, g.(types.FactStats).Facts())
+		case output == "":
+			err = write(os.Stdout)
+		default:
+			err = writeFile(output, write)
+		}
 
-		if err := g.Err(); err != nil {
+		if err != nil {
 			return err
 		}
 
-		format, err := pFlags.GetString("format")
+		if len(stats.Failures) == 0 {
+			return nil
+		}
+
+		failOnErrors, err := cmd.Flags().GetBool("fail-on-errors")
 		if err != nil {
 			return err
 		}
 
-		switch types.Format(format) {
-		case types.Json:
-			fmt.Println(json.Marshal(&stats))
-		case types.Txt:
-			fmt.Println(stats.String())
-		default:
-			return fmt.Errorf("unknown format: %s", format)
+		if failOnErrors {
+			return fmt.Errorf("statistics are incomplete: %s", stats.Failures.Summary())
 		}
 
+		logrus.Warnf("statistics are incomplete: %s", stats.Failures.Summary())
+
 		return nil
 	},
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		pFlags := cmd.PersistentFlags()
+		pFlags := cmd.Flags()
 		server, err := pFlags.GetString("server")
 		if err != nil {
 			return err
@@ -135,6 +157,7 @@ var cmd = &cobra.Command{
 					return err
 				}
 			}
+		case types.Local:
 		default:
 			return fmt.Errorf("invalid Git server %q", server)
 		}
@@ -151,18 +174,48 @@ var cmd = &cobra.Command{
 }
 
 func init() {
+	cmd.Flags().String("baseline", "", "Previous json report, projects which head didn't move since are taken from it instead of being processed")
+	cmd.Flags().String("checkpoint", "", "File recording completed projects during the run, $XDG_CACHE_HOME/gitstat/checkpoint-<hash of the flags>.jsonl by default")
+	cmd.Flags().Bool("resume", false, "Skip projects completed by the interrupted run with the same flags, can't be used with --commits, --merge-requests or --format sqlite")
+	cmd.Flags().String("template", "", "Go template file rendering the report with --format template, html/template is used for .html files")
+	cmd.Flags().StringP("output-file", "o", "", "File to write the output to instead of stdout, replaced atomically, required with --format sqlite")
+	cmd.Flags().Bool("fail-on-errors", false, "Exit with an error if any project or file failed to be processed")
+
 	pFlags := cmd.PersistentFlags()
 
 	pFlags.StringSliceP("user", "u", []string{}, "User aliases in form email:alias")
-	pFlags.StringSliceP("lang", "l", []string{}, "Language file extensions in form lang:extension")
+	pFlags.StringSliceP("lang", "l", []string{}, "Language file extensions in form lang:extension overriding or extending the built-in languages")
 	pFlags.StringP("server", "s", "gitlab", "Git server type")
 	pFlags.StringP("token", "t", "", "Git server authentication token")
 	pFlags.StringP("host", "H", "", "Git server host")
-	pFlags.StringP("format", "f", "txt", "Output format")
-	pFlags.StringP("query", "q", "", "Projects query")
+	pFlags.StringP("format", "f", "txt", "Output format: txt, json, csv, tsv, markdown, html, template, openmetrics or sqlite")
+	pFlags.StringP("query", "q", "", "Projects query for GitLab, organization or user for GitHub")
 	pFlags.IntP("retry", "r", 5, "Git server call retries")
 	pFlags.IntP("rate", "R", 50, "Git server rate limit")
+	pFlags.Int("concurrency-projects", 8, "Number of projects processed at once, unbounded if 0")
+	pFlags.Int("concurrency-files", 32, "Number of files processed at once in all projects (GitLab and GitHub), unbounded if 0")
 	pFlags.IntP("verbosity", "v", int(logrus.GetLevel()), "Verbosity level")
 	pFlags.BoolP("silent", "S", false, "Don't output progress")
-	pFlags.StringP("exclude", "E", "", "Regex for excluding projects")
+	pFlags.StringArrayP("exclude", "E", []string{}, "Regex for excluding projects, can be repeated")
+	pFlags.StringArrayP("include", "I", []string{}, "Regex for including projects, can be repeated, all projects are included if empty")
+	pFlags.StringArray("path-exclude", []string{}, "Glob for excluding files inside repositories, ** matches any directories, can be repeated")
+	pFlags.StringArray("path-include", []string{}, "Glob for including files inside repositories, ** matches any directories, can be repeated, all files are included if empty")
+	pFlags.Bool("include-generated", false, "Count generated files like regular ones instead of reporting them separately")
+	pFlags.Bool("include-vendored", false, "Count vendored files like regular ones instead of reporting them separately")
+	pFlags.StringArrayP("group", "g", []string{}, "GitLab group to restrict projects to including subgroups, can be repeated")
+	pFlags.Bool("exclude-archived", false, "Exclude archived projects")
+	pFlags.Bool("exclude-forks", false, "Exclude forked projects")
+	pFlags.StringSlice("exclude-visibility", []string{}, "Exclude projects by visibility: private, internal or public")
+	pFlags.Bool("commits", false, "Count commits, added and deleted lines")
+	pFlags.Bool("commit-languages", false, "Split added and deleted lines of commits per language, needs a diff request per commit with the GitLab API")
+	pFlags.Bool("merge-requests", false, "Count merge requests, approvals and comments (GitLab)")
+	pFlags.String("since", "", "Only count changes since the date in 2006-01-02 or RFC 3339 format")
+	pFlags.String("until", "", "Only count changes before the date in 2006-01-02 or RFC 3339 format")
+	pFlags.StringSliceP("dir", "d", []string{"."}, "Local repositories or directories containing repositories")
+	pFlags.String("ref", "", "Git reference to compute local statistics at, HEAD by default")
+	pFlags.Bool("clone", false, "Clone GitLab projects and compute blame locally")
+	pFlags.String("clone-dir", "", "Directory to keep clones in between runs, temporary directories are used if empty")
+	pFlags.Int("clone-depth", 0, "Depth of shallow clones, full history if 0. Lines older than a shallow clone are counted as other")
+	pFlags.Bool("no-cache", false, "Don't read or store blame in the cache")
+	pFlags.String("cache-path", "", "Blame cache file, $XDG_CACHE_HOME/gitstat/blame.db by default")
 }
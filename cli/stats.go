@@ -0,0 +1,388 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/cache"
+	"github.com/gaarutyunov/gitstat/filter"
+	"github.com/gaarutyunov/gitstat/github"
+	"github.com/gaarutyunov/gitstat/gitlab"
+	"github.com/gaarutyunov/gitstat/local"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/gaarutyunov/gitstat/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"os"
+	"time"
+)
+
+type (
+	statsConfig struct {
+		commits         bool
+		commitLanguages bool
+		mergeRequests   bool
+		since           time.Time
+		until           time.Time
+		at              time.Time
+		baseline        *models.Baseline
+		checkpoint      *models.Checkpoint
+		owners          bool
+		facts           bool
+	}
+
+	statsOption func(*statsConfig)
+)
+
+// withAt computes line statistics at the last commit before t without commits, merge requests and date range.
+func withAt(t time.Time) statsOption {
+	return func(c *statsConfig) {
+		c.at = t
+		c.commits = false
+		c.mergeRequests = false
+		c.since = time.Time{}
+		c.until = time.Time{}
+	}
+}
+
+// withOwners counts lines per file for ownership without commits and merge requests.
+func withOwners() statsOption {
+	return func(c *statsConfig) {
+		c.owners = true
+		c.commits = false
+		c.mergeRequests = false
+	}
+}
+
+// withFacts keeps the blame ranges of every file if enabled, the blame cache isn't used since it doesn't store commits.
+func withFacts(enabled bool) statsOption {
+	return func(c *statsConfig) {
+		c.facts = enabled
+	}
+}
+
+// withBaseline restores projects which head didn't move since the baseline instead of processing them.
+func withBaseline(b *models.Baseline) statsOption {
+	return func(c *statsConfig) {
+		c.baseline = b
+	}
+}
+
+// withCheckpoint writes every processed project to the checkpoint.
+func withCheckpoint(checkpoint *models.Checkpoint) statsOption {
+	return func(c *statsConfig) {
+		c.checkpoint = checkpoint
+	}
+}
+
+// loadBaseline reads the report at path written with the json format.
+func loadBaseline(path string) (*models.Baseline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := models.LoadBaseline(f)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("error reading baseline %s", path), err)
+	}
+
+	return b, nil
+}
+
+func newProjectFilter(flags *pflag.FlagSet) (*filter.Projects, error) {
+	include, err := flags.GetStringArray("include")
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := flags.GetStringArray("exclude")
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := filter.NewProjects(include, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	f.ExcludeArchived, err = flags.GetBool("exclude-archived")
+	if err != nil {
+		return nil, err
+	}
+	f.ExcludeForks, err = flags.GetBool("exclude-forks")
+	if err != nil {
+		return nil, err
+	}
+	f.ExcludeVisibility, err = flags.GetStringSlice("exclude-visibility")
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func newPathFilter(flags *pflag.FlagSet) (*filter.Paths, error) {
+	include, err := flags.GetStringArray("path-include")
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := flags.GetStringArray("path-exclude")
+	if err != nil {
+		return nil, err
+	}
+
+	return filter.NewPaths(include, exclude)
+}
+
+// includedKinds returns kinds of files counted like regular ones.
+func includedKinds(flags *pflag.FlagSet) (kinds []types.FileKind, err error) {
+	for kind, name := range map[types.FileKind]string{
+		types.Generated: "include-generated",
+		types.Vendored:  "include-vendored",
+	} {
+		include, err := flags.GetBool(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if include {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	return kinds, nil
+}
+
+// newStats creates statistics of the Git server configured by the flags of cmd.
+func newStats(cmd *cobra.Command, opts ...statsOption) (types.Stats, error) {
+	flags := cmd.Flags()
+
+	server, err := flags.GetString("server")
+	if err != nil {
+		return nil, err
+	}
+	host, err := flags.GetString("host")
+	if err != nil {
+		return nil, err
+	}
+	token, err := flags.GetString("token")
+	if err != nil {
+		return nil, err
+	}
+	rateLimit, err := flags.GetInt("rate")
+	if err != nil {
+		return nil, err
+	}
+	userAliases, err := flags.GetStringSlice("user")
+	if err != nil {
+		return nil, err
+	}
+	langExtensions, err := flags.GetStringSlice("lang")
+	if err != nil {
+		return nil, err
+	}
+	silent, err := flags.GetBool("silent")
+	if err != nil {
+		return nil, err
+	}
+	concurrentProjects, err := flags.GetInt("concurrency-projects")
+	if err != nil {
+		return nil, err
+	}
+	concurrentFiles, err := flags.GetInt("concurrency-files")
+	if err != nil {
+		return nil, err
+	}
+
+	query, _ := flags.GetString("query")
+
+	var cfg statsConfig
+
+	cfg.commits, err = flags.GetBool("commits")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.commitLanguages, err = flags.GetBool("commit-languages")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.mergeRequests, err = flags.GetBool("merge-requests")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.since, err = getTime(flags, "since")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.until, err = getTime(flags, "until")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.baseline != nil && (cfg.commits || cfg.mergeRequests) {
+		return nil, errors.New("--baseline and --resume only restore line statistics and can't be used with --commits or --merge-requests")
+	}
+
+	if cfg.baseline != nil && cfg.facts {
+		return nil, errors.New("--baseline and --resume don't restore blame ranges and can't be used with --format sqlite")
+	}
+
+	retries, err := flags.GetInt("retry")
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(utils.AliasMap[types.User])
+
+	err = users.Parse(userAliases)
+	if err != nil {
+		return nil, err
+	}
+
+	extensions := make(utils.AliasMap[types.Language])
+
+	err = extensions.Parse(langExtensions)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := newProjectFilter(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := newPathFilter(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	included, err := includedKinds(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	var blames *cache.Cache
+
+	if types.GitServer(server) != types.Local && !cfg.facts {
+		blames, err = openCache(flags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var g types.Stats
+
+	switch types.GitServer(server) {
+	case types.Gitlab:
+		clone, err := flags.GetBool("clone")
+		if err != nil {
+			return nil, err
+		}
+		cloneDir, err := flags.GetString("clone-dir")
+		if err != nil {
+			return nil, err
+		}
+		cloneDepth, err := flags.GetInt("clone-depth")
+		if err != nil {
+			return nil, err
+		}
+		groups, err := flags.GetStringArray("group")
+		if err != nil {
+			return nil, err
+		}
+		gitlab.SetRetries(retries)
+		g = gitlab.New(
+			host,
+			token,
+			gitlab.WithRateLimit(rateLimit),
+			gitlab.WithRetries(retries),
+			gitlab.WithUsers(users.ToSlice(models.NewUser)...),
+			gitlab.WithLanguages(extensions.ToSlice(models.NewLanguage)...),
+			gitlab.WithQuery(query),
+			gitlab.WithFilter(projects),
+			gitlab.WithPaths(paths),
+			gitlab.WithIncluded(included...),
+			gitlab.WithCache(blames),
+			gitlab.WithGroups(groups...),
+			gitlab.WithContext(cmd.Context()),
+			gitlab.WithProgress(!silent),
+			gitlab.WithCommits(cfg.commits),
+			gitlab.WithCommitLanguages(cfg.commitLanguages),
+			gitlab.WithMergeRequests(cfg.mergeRequests),
+			gitlab.WithSince(cfg.since),
+			gitlab.WithUntil(cfg.until),
+			gitlab.WithAt(cfg.at),
+			gitlab.WithBaseline(cfg.baseline),
+			gitlab.WithCheckpoint(cfg.checkpoint),
+			gitlab.WithOwners(cfg.owners),
+			gitlab.WithFacts(cfg.facts),
+			gitlab.WithConcurrency(concurrentProjects, concurrentFiles),
+			gitlab.WithClone(clone, cloneDir, cloneDepth),
+		)
+	case types.GitHub:
+		g = github.New(
+			host,
+			token,
+			github.WithRateLimit(rateLimit),
+			github.WithRetries(retries),
+			github.WithUsers(users.ToSlice(models.NewUser)...),
+			github.WithLanguages(extensions.ToSlice(models.NewLanguage)...),
+			github.WithOwner(query),
+			github.WithFilter(projects),
+			github.WithPaths(paths),
+			github.WithIncluded(included...),
+			github.WithCache(blames),
+			github.WithContext(cmd.Context()),
+			github.WithProgress(!silent),
+			github.WithCommits(cfg.commits),
+			github.WithSince(cfg.since),
+			github.WithUntil(cfg.until),
+			github.WithAt(cfg.at),
+			github.WithBaseline(cfg.baseline),
+			github.WithCheckpoint(cfg.checkpoint),
+			github.WithOwners(cfg.owners),
+			github.WithFacts(cfg.facts),
+			github.WithConcurrency(concurrentProjects, concurrentFiles),
+		)
+	case types.Local:
+		dirs, err := flags.GetStringSlice("dir")
+		if err != nil {
+			return nil, err
+		}
+		ref, err := flags.GetString("ref")
+		if err != nil {
+			return nil, err
+		}
+		g = local.New(
+			dirs,
+			local.WithRef(ref),
+			local.WithFilter(projects),
+			local.WithPaths(paths),
+			local.WithIncluded(included...),
+			local.WithUsers(users.ToSlice(models.NewUser)...),
+			local.WithLanguages(extensions.ToSlice(models.NewLanguage)...),
+			local.WithContext(cmd.Context()),
+			local.WithProgress(!silent),
+			local.WithCommits(cfg.commits),
+			local.WithSince(cfg.since),
+			local.WithUntil(cfg.until),
+			local.WithAt(cfg.at),
+			local.WithBaseline(cfg.baseline),
+			local.WithCheckpoint(cfg.checkpoint),
+			local.WithOwners(cfg.owners),
+			local.WithFacts(cfg.facts),
+			local.WithConcurrency(concurrentProjects),
+		)
+	}
+
+	return g, nil
+}
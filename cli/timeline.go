@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/spf13/cobra"
+	"os"
+	"slices"
+	"time"
+)
+
+const (
+	month = "month"
+	week  = "week"
+)
+
+var timelineCmd = &cobra.Command{
+	Use:   "timeline",
+	Short: "Line statistics at the end of every month or week",
+	Long: `Computes line statistics at the last commit of the default branch before the end of every period.
+Periods end between --since and --until, or the last --periods periods are used if --since is empty.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+
+		interval, err := flags.GetString("interval")
+		if err != nil {
+			return err
+		}
+		periods, err := flags.GetInt("periods")
+		if err != nil {
+			return err
+		}
+		since, err := getTime(flags, "since")
+		if err != nil {
+			return err
+		}
+		until, err := getTime(flags, "until")
+		if err != nil {
+			return err
+		}
+
+		if until.IsZero() {
+			until = time.Now()
+		}
+
+		dates, err := timelineDates(since, until, interval, periods)
+		if err != nil {
+			return err
+		}
+
+		timeline := make(models.Timeline, len(dates))
+
+		for _, date := range dates {
+			g, err := newStats(cmd, withAt(date))
+			if err != nil {
+				return err
+			}
+
+			stats := models.NewStats(g)
+
+			if err := g.Err(); err != nil {
+				return err
+			}
+
+			timeline[date.Add(-time.Nanosecond).Format(time.DateOnly)] = stats
+		}
+
+		format, err := flags.GetString("format")
+		if err != nil {
+			return err
+		}
+
+		switch types.Format(format) {
+		case types.Json:
+			b, err := json.Marshal(timeline)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+		case types.Csv:
+			return timeline.WriteCSV(os.Stdout, ',')
+		case types.Tsv:
+			return timeline.WriteCSV(os.Stdout, '\t')
+		case types.Txt:
+			fmt.Println(timeline.String())
+		default:
+			return fmt.Errorf("unknown format: %s", format)
+		}
+
+		return nil
+	},
+}
+
+// timelineDates returns the exclusive ends of periods before until in ascending order ending with until itself.
+func timelineDates(since, until time.Time, interval string, periods int) ([]time.Time, error) {
+	var (
+		end  time.Time
+		prev func(t time.Time) time.Time
+	)
+
+	switch interval {
+	case month:
+		end = time.Date(until.Year(), until.Month(), 1, 0, 0, 0, 0, until.Location())
+		prev = func(t time.Time) time.Time {
+			return t.AddDate(0, -1, 0)
+		}
+	case week:
+		day := time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, until.Location())
+		end = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		prev = func(t time.Time) time.Time {
+			return t.AddDate(0, 0, -7)
+		}
+	default:
+		return nil, fmt.Errorf("unknown interval: %s", interval)
+	}
+
+	dates := []time.Time{until}
+
+	for ; ; end = prev(end) {
+		if since.IsZero() && len(dates) >= periods {
+			break
+		}
+
+		if !since.IsZero() && !end.After(since) {
+			break
+		}
+
+		if end.Before(until) {
+			dates = append(dates, end)
+		}
+	}
+
+	slices.Reverse(dates)
+
+	return dates, nil
+}
+
+func init() {
+	flags := timelineCmd.Flags()
+
+	flags.String("interval", month, "Interval between snapshots: month or week")
+	flags.IntP("periods", "n", 12, "Number of snapshots if --since is empty")
+
+	cmd.AddCommand(timelineCmd)
+}
@@ -1,15 +1,19 @@
 package cli
 
 import (
+	"fmt"
+	"github.com/gaarutyunov/gitstat/utils"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"os"
 	"strings"
+	"time"
 )
 
 func bindToEnv(cmd *cobra.Command, prefix string, flags ...string) error {
 	for _, flag := range flags {
-		if v, _ := cmd.PersistentFlags().GetString(flag); v == "" {
-			err := cmd.PersistentFlags().Set(flag, os.Getenv(strings.ToUpper(prefix)+"_"+strings.ToUpper(flag)))
+		if v, _ := cmd.Flags().GetString(flag); v == "" {
+			err := cmd.Flags().Set(flag, os.Getenv(strings.ToUpper(prefix)+"_"+strings.ToUpper(flag)))
 			if err != nil {
 				return err
 			}
@@ -18,3 +22,17 @@ func bindToEnv(cmd *cobra.Command, prefix string, flags ...string) error {
 
 	return nil
 }
+
+func getTime(flags *pflag.FlagSet, name string) (time.Time, error) {
+	v, err := flags.GetString(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t, err := utils.ParseTime(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s: %w", name, err)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,111 @@
+package filter
+
+import (
+	"fmt"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"slices"
+)
+
+// ConfigFile is the repository configuration file read from the default branch.
+const ConfigFile = ".gitstat.yml"
+
+type (
+	// Paths filters files inside repositories by glob patterns supporting **.
+	Paths struct {
+		Include []string `yaml:"include"`
+		Exclude []string `yaml:"exclude"`
+	}
+
+	// Config is the repository configuration stored in ConfigFile.
+	Config struct {
+		Paths Paths `yaml:"paths"`
+	}
+)
+
+// NewPaths creates a filter including files which path matches any of include patterns
+// and none of exclude patterns. All files are included if include is empty.
+func NewPaths(include, exclude []string) (*Paths, error) {
+	f := &Paths{Include: include, Exclude: exclude}
+
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// ParseConfig parses the contents of ConfigFile.
+func ParseConfig(data []byte) (*Config, error) {
+	var config Config
+
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ConfigFile, err)
+	}
+
+	if err := config.Paths.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ConfigFile, err)
+	}
+
+	return &config, nil
+}
+
+func (f *Paths) validate() error {
+	for _, pattern := range slices.Concat(f.Include, f.Exclude) {
+		if !doublestar.ValidatePattern(pattern) {
+			return fmt.Errorf("invalid path pattern %q", pattern)
+		}
+	}
+
+	return nil
+}
+
+// With returns the filter overridden by the repository config:
+// include patterns of the config replace the ones of f and exclude patterns are added.
+func (f *Paths) With(config *Config) *Paths {
+	if config == nil {
+		return f
+	}
+
+	res := &Paths{
+		Include: f.Include,
+		Exclude: slices.Concat(f.Exclude, config.Paths.Exclude),
+	}
+
+	if len(config.Paths.Include) != 0 {
+		res.Include = config.Paths.Include
+	}
+
+	return res
+}
+
+// Skip returns the reason to skip the file at path or an empty string if it passes the filter.
+func (f *Paths) Skip(path string) string {
+	if len(f.Include) != 0 && !slices.ContainsFunc(f.Include, func(pattern string) bool {
+		return doublestar.MatchUnvalidated(pattern, path)
+	}) {
+		return "doesn't match include patterns"
+	}
+
+	for _, pattern := range f.Exclude {
+		if doublestar.MatchUnvalidated(pattern, path) {
+			return fmt.Sprintf("matches exclude pattern %s", pattern)
+		}
+	}
+
+	return ""
+}
+
+// Language wraps the language resolver of files to skip paths filtered by f.
+func (f *Paths) Language(lang types.LanguageResolver) types.LanguageResolver {
+	return func(path string, content func() ([]byte, error)) (types.Language, bool) {
+		if reason := f.Skip(path); reason != "" {
+			logrus.Debugf("skipping file %s: %s", path, reason)
+			return nil, false
+		}
+
+		return lang(path, content)
+	}
+}
@@ -0,0 +1,81 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+type (
+	// Project holds the attributes of a project projects are filtered by.
+	Project struct {
+		Path       string
+		Archived   bool
+		Fork       bool
+		Visibility string
+	}
+
+	// Projects filters projects by path patterns, archived and fork status and visibility.
+	Projects struct {
+		Include           []*regexp.Regexp
+		Exclude           []*regexp.Regexp
+		ExcludeArchived   bool
+		ExcludeForks      bool
+		ExcludeVisibility []string
+	}
+)
+
+// NewProjects creates a filter including projects which path matches any of include patterns
+// and none of exclude patterns. All projects are included if include is empty.
+func NewProjects(include, exclude []string) (*Projects, error) {
+	f := &Projects{}
+
+	for _, pattern := range include {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+		}
+
+		f.Include = append(f.Include, re)
+	}
+
+	for _, pattern := range exclude {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+
+		f.Exclude = append(f.Exclude, re)
+	}
+
+	return f, nil
+}
+
+// Skip returns the reason to skip p or an empty string if p passes the filter.
+func (f *Projects) Skip(p Project) string {
+	if f.ExcludeArchived && p.Archived {
+		return "archived"
+	}
+
+	if f.ExcludeForks && p.Fork {
+		return "fork"
+	}
+
+	if p.Visibility != "" && slices.Contains(f.ExcludeVisibility, p.Visibility) {
+		return fmt.Sprintf("visibility %s", p.Visibility)
+	}
+
+	if len(f.Include) != 0 && !slices.ContainsFunc(f.Include, func(re *regexp.Regexp) bool {
+		return re.MatchString(p.Path)
+	}) {
+		return "doesn't match include patterns"
+	}
+
+	for _, re := range f.Exclude {
+		if re.MatchString(p.Path) {
+			return fmt.Sprintf("matches exclude pattern %s", re)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,233 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gaarutyunov/gitstat/cache"
+	"github.com/gaarutyunov/gitstat/languages"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/google/go-github/v66/github"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const blameQuery = `query($owner: String!, $name: String!, $ref: String!, $file: String!, $path: String!) {
+  repository(owner: $owner, name: $name) {
+    commit: object(expression: $ref) {
+      ... on Commit {
+        blame(path: $path) {
+          ranges {
+            startingLine
+            endingLine
+            commit {
+              oid
+              committedDate
+              author {
+                name
+                email
+                user {
+                  login
+                }
+              }
+              committer {
+                name
+                email
+              }
+            }
+          }
+        }
+      }
+    }
+    blob: object(expression: $file) {
+      ... on Blob {
+        text
+        isBinary
+      }
+    }
+  }
+}`
+
+type (
+	BlameRange struct {
+		StartingLine int `json:"startingLine"`
+		EndingLine   int `json:"endingLine"`
+		Commit       struct {
+			Oid           string    `json:"oid"`
+			CommittedDate time.Time `json:"committedDate"`
+			Author        struct {
+				Name  string `json:"name"`
+				Email string `json:"email"`
+				User  *struct {
+					Login string `json:"login"`
+				} `json:"user"`
+			} `json:"author"`
+			Committer struct {
+				Name  string `json:"name"`
+				Email string `json:"email"`
+			} `json:"committer"`
+		} `json:"commit"`
+		Lines []string `json:"-"`
+	}
+
+	graphqlRequest struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+
+	graphqlError struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+
+	blameResponse struct {
+		Data struct {
+			Repository *struct {
+				Commit *struct {
+					Blame struct {
+						Ranges []*BlameRange `json:"ranges"`
+					} `json:"blame"`
+				} `json:"commit"`
+				Blob *struct {
+					Text     *string `json:"text"`
+					IsBinary bool    `json:"isBinary"`
+				} `json:"blob"`
+			} `json:"repository"`
+		} `json:"data"`
+		Errors []graphqlError `json:"errors"`
+	}
+)
+
+var ErrNotFound = errors.New("not found")
+
+// AuthorLogin returns the GitHub login of the author if the commit is linked to an account.
+func (r *BlameRange) AuthorLogin() string {
+	if r.Commit.Author.User == nil {
+		return ""
+	}
+
+	return r.Commit.Author.User.Login
+}
+
+// getFileBlame requests blame ranges and contents of the file at ref in a single GraphQL query
+// and fills the Lines of every range.
+func (s *Stats) getFileBlame(ctx context.Context, owner, name, ref, path string) ([]*BlameRange, error) {
+	body, err := json.Marshal(&graphqlRequest{
+		Query: blameQuery,
+		Variables: map[string]any{
+			"owner": owner,
+			"name":  name,
+			"ref":   ref,
+			"file":  ref + ":" + path,
+			"path":  path,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.graphqlURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.client.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := github.CheckResponse(res); err != nil {
+		return nil, err
+	}
+
+	var blame blameResponse
+
+	if err := json.NewDecoder(res.Body).Decode(&blame); err != nil {
+		return nil, err
+	}
+
+	if len(blame.Errors) != 0 {
+		errs := make([]error, 0, len(blame.Errors))
+
+		for _, e := range blame.Errors {
+			if e.Type == "NOT_FOUND" {
+				errs = append(errs, ErrNotFound)
+			}
+			errs = append(errs, errors.New(e.Message))
+		}
+
+		return nil, errors.Join(errs...)
+	}
+
+	repo := blame.Data.Repository
+	if repo == nil || repo.Commit == nil || repo.Blob == nil || repo.Blob.Text == nil {
+		return nil, ErrNotFound
+	}
+
+	if repo.Blob.IsBinary {
+		return nil, nil
+	}
+
+	lines := strings.Split(*repo.Blob.Text, "\n")
+	ranges := repo.Commit.Blame.Ranges
+
+	for _, blameRange := range ranges {
+		start, end := blameRange.StartingLine-1, blameRange.EndingLine
+
+		if start < 0 || end > len(lines) || start > end {
+			continue
+		}
+
+		blameRange.Lines = lines[start:end]
+	}
+
+	return ranges, nil
+}
+
+// getBlame requests the blame of the file at path and ref aggregating lines by author,
+// since commits merged in the web UI are committed by GitHub itself.
+// Ranges are the blame ranges as they are returned.
+func (s *Stats) getBlame(ctx context.Context, owner, name, ref, path string, lang types.Language) (blame *cache.Blame, ranges []types.BlameRange, err error) {
+	blameRanges, err := s.getFileBlame(ctx, owner, name, ref, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var lines []string
+	for _, blameRange := range blameRanges {
+		lines = append(lines, blameRange.Lines...)
+	}
+
+	blame = &cache.Blame{Generated: languages.Sniff(path, lines)}
+	scanner := models.NewLineScanner(lang)
+
+	for _, blameRange := range blameRanges {
+		commit := blameRange.Commit
+
+		r := types.BlameRange{
+			Commit:    commit.Oid,
+			Author:    types.Person{Name: commit.Author.Name, Email: commit.Author.Email},
+			Committer: types.Person{Name: commit.Committer.Name, Email: commit.Committer.Email},
+			Date:      commit.CommittedDate,
+			Lines:     make(map[types.LineKind]int64, 3),
+		}
+
+		// every line is scanned to track block comments spanning ranges
+		for _, line := range blameRange.Lines {
+			kind := scanner.Scan(line)
+
+			blame.Add(commit.Author.Email, blameRange.AuthorLogin(), commit.CommittedDate, kind, 1)
+			r.Lines[kind]++
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	return blame, ranges, nil
+}
@@ -0,0 +1,111 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/google/go-github/v66/github"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// processCommits counts changes of non-merge commits on the default branch of repo.
+func (s *Stats) processCommits(repo *github.Repository, language types.LanguageResolver) error {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+
+	opts := &github.CommitsListOptions{
+		SHA: repo.GetDefaultBranch(),
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+			Page:    1,
+		},
+		Since: s.period.Since,
+		Until: s.period.Until,
+	}
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		default:
+		}
+
+		commits, res, err := s.client.Repositories.ListCommits(s.ctx, owner, name, opts)
+		if err != nil {
+			var errRes *github.ErrorResponse
+			if errors.As(err, &errRes) && errRes.Response.StatusCode == http.StatusConflict {
+				logrus.Debugf("empty history for repo %s", repo.GetFullName())
+				return nil
+			}
+			return errors.Join(fmt.Errorf("error listing commits for repository %s", repo.GetFullName()), err)
+		}
+
+		for _, commit := range commits {
+			if len(commit.Parents) > 1 {
+				continue
+			}
+
+			total, changes, err := s.getCommitChanges(owner, name, commit.GetSHA(), language)
+			if err != nil {
+				if errors.Is(err, s.ctx.Err()) {
+					return err
+				}
+				logrus.Debugf("error getting commit %s in repository %s: %v", commit.GetSHA(), repo.GetFullName(), err)
+				continue
+			}
+
+			user := s.getUser(commit.GetCommit().GetAuthor().GetEmail(), commit.GetAuthor().GetLogin())
+
+			s.commits.Add(repo.GetFullName(), user, total, changes)
+		}
+
+		if res.NextPage == 0 {
+			break
+		} else {
+			opts.Page = res.NextPage
+		}
+	}
+
+	return nil
+}
+
+// getCommitChanges returns additions and deletions of the commit and of its files per language.
+func (s *Stats) getCommitChanges(owner, name, sha string, language types.LanguageResolver) (total types.CommitCount, changes map[types.Language]types.CommitCount, err error) {
+	opts := &github.ListOptions{
+		PerPage: 100,
+		Page:    1,
+	}
+
+	changes = make(map[types.Language]types.CommitCount)
+
+	for {
+		commit, res, err := s.client.Repositories.GetCommit(s.ctx, owner, name, sha, opts)
+		if err != nil {
+			return total, nil, err
+		}
+
+		if opts.Page == 1 {
+			total.Additions, total.Deletions = commit.GetStats().GetAdditions(), commit.GetStats().GetDeletions()
+		}
+
+		for _, file := range commit.Files {
+			lang, ok := language(file.GetFilename(), nil)
+			if !ok {
+				continue
+			}
+
+			n := changes[lang]
+			n.Additions += file.GetAdditions()
+			n.Deletions += file.GetDeletions()
+			changes[lang] = n
+		}
+
+		if res.NextPage == 0 {
+			break
+		} else {
+			opts.Page = res.NextPage
+		}
+	}
+
+	return total, changes, nil
+}
@@ -0,0 +1,178 @@
+package github
+
+import (
+	"context"
+	"github.com/gaarutyunov/gitstat/cache"
+	"github.com/gaarutyunov/gitstat/filter"
+	"github.com/gaarutyunov/gitstat/languages"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/gaarutyunov/gitstat/utils"
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/time/rate"
+	"time"
+)
+
+// WithLanguages overrides or extends the built-in languages detected in repositories.
+func WithLanguages(langs ...types.Language) Option {
+	return func(g *Stats) {
+		g.detector = languages.New(langs...)
+	}
+}
+
+func WithUsers(users ...types.User) Option {
+	return func(g *Stats) {
+		for _, user := range users {
+			g.userAliases[user.GetEmail()] = user.GetAliases()
+			for _, alias := range user.GetAliases() {
+				g.userByAlias[alias] = user
+			}
+		}
+	}
+}
+
+func WithRateLimit(n int) Option {
+	return func(g *Stats) {
+		g.rl = rate.NewLimiter(rate.Limit(n), 1)
+	}
+}
+
+func WithRetries(n int) Option {
+	return func(g *Stats) {
+		g.retries = n
+	}
+}
+
+func WithContext(ctx context.Context) Option {
+	return func(g *Stats) {
+		g.ctx = ctx
+	}
+}
+
+func WithProgress(progress bool, option ...progressbar.Option) Option {
+	return func(g *Stats) {
+		if !progress {
+			g.progress = nil
+		} else {
+			g.progress = func(n int64) *progressbar.ProgressBar {
+				if len(option) == 0 {
+					return progressbar.Default(n)
+				}
+				return progressbar.NewOptions64(n, option...)
+			}
+		}
+	}
+}
+
+// WithOwner sets the organization or user whose repositories are processed.
+// Repositories of the authenticated user are processed if owner is empty.
+func WithOwner(owner string) Option {
+	return func(g *Stats) {
+		g.owner = owner
+	}
+}
+
+// WithFilter sets the filter applied to projects before they are processed.
+func WithFilter(f *filter.Projects) Option {
+	return func(g *Stats) {
+		if f != nil {
+			g.filter = f
+		}
+	}
+}
+
+// WithPaths sets the filter of files by their paths inside repositories.
+func WithPaths(f *filter.Paths) Option {
+	return func(g *Stats) {
+		if f != nil {
+			g.pathFilter = f
+		}
+	}
+}
+
+// WithCache reuses blame of files unchanged since it was stored in c.
+func WithCache(c *cache.Cache) Option {
+	return func(g *Stats) {
+		g.cache = c
+	}
+}
+
+// WithIncluded counts lines of generated or vendored files like the ones of regular files.
+func WithIncluded(kinds ...types.FileKind) Option {
+	return func(g *Stats) {
+		g.include = kinds
+	}
+}
+
+// WithCommits enables counting of commits on the default branch of every repository.
+func WithCommits(commits bool) Option {
+	return func(g *Stats) {
+		if commits {
+			g.commits = models.NewCommitCounter()
+		} else {
+			g.commits = nil
+		}
+	}
+}
+
+// WithSince restricts statistics to lines and commits since t.
+func WithSince(t time.Time) Option {
+	return func(g *Stats) {
+		g.period.Since = t
+	}
+}
+
+// WithUntil restricts statistics to lines and commits before t.
+func WithUntil(t time.Time) Option {
+	return func(g *Stats) {
+		g.period.Until = t
+	}
+}
+
+// WithAt computes line statistics at the last commit before t instead of the head of the default branch.
+func WithAt(t time.Time) Option {
+	return func(g *Stats) {
+		g.at = t
+	}
+}
+
+// WithBaseline restores repositories which head didn't move since the baseline report instead of processing them.
+func WithBaseline(b *models.Baseline) Option {
+	return func(g *Stats) {
+		g.baseline = b
+	}
+}
+
+// WithCheckpoint writes every processed project to the checkpoint.
+func WithCheckpoint(c *models.Checkpoint) Option {
+	return func(g *Stats) {
+		g.checkpoint = c
+	}
+}
+
+// WithConcurrency bounds the number of projects and files processed at once, 0 means no bound.
+// The file bound is shared by all projects.
+func WithConcurrency(projects, files int) Option {
+	return func(g *Stats) {
+		g.projectPool = utils.NewPool(projects)
+		g.filePool = utils.NewPool(files)
+	}
+}
+
+// WithOwners counts lines per file of every repository to report their owners.
+func WithOwners(enabled bool) Option {
+	return func(g *Stats) {
+		if enabled {
+			g.owners = models.NewOwnerCounters()
+		}
+	}
+}
+
+// WithFacts keeps the blame ranges of every file, the blame cache should be disabled since it doesn't store commits.
+func WithFacts(enabled bool) Option {
+	return func(g *Stats) {
+		if enabled {
+			g.facts = models.NewFacts()
+		}
+	}
+}
@@ -0,0 +1,844 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/cache"
+	"github.com/gaarutyunov/gitstat/filter"
+	"github.com/gaarutyunov/gitstat/languages"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/owners"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/gaarutyunov/gitstat/utils"
+	"github.com/google/go-github/v66/github"
+	"github.com/schollz/progressbar/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/ybbus/httpretry"
+	"golang.org/x/time/rate"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	Stats struct {
+		ctx         context.Context
+		baseURL     *url.URL
+		graphqlURL  *url.URL
+		token       string
+		owner       string
+		ownerType   string
+		client      *github.Client
+		so          sync.Once
+		detector    *languages.Detector
+		userAliases map[string][]string
+		userByAlias map[string]types.User
+		counter     map[types.User]types.PerLanguageCounter
+		se          sync.Once
+		err         error
+		retries     int
+		rl          *rate.Limiter
+		progress    func(n int64) *progressbar.ProgressBar
+		filter      *filter.Projects
+		cache       *cache.Cache
+		include     []types.FileKind
+		excluded    models.MapKindCounter
+		projects    *models.ProjectCounters
+		baseline    *models.Baseline
+		checkpoint  *models.Checkpoint
+		projectPool *utils.Pool
+		filePool    *utils.Pool
+		failures    models.Failures
+		owners      *models.OwnerCounters
+		facts       *models.Facts
+		pathFilter  *filter.Paths
+		commits     *models.CommitCounter
+		period      utils.Period
+		at          time.Time
+	}
+
+	// limitedTransport waits for the rate limiter before every request.
+	limitedTransport struct {
+		rl   *rate.Limiter
+		base http.RoundTripper
+	}
+)
+
+const organizationType = "Organization"
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.rl.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+func (s *Stats) Err() error {
+	return s.err
+}
+
+type Option func(*Stats)
+
+// New creates GitHub statistics for the server at baseURL.
+// An empty host or github.com uses the public API, any other host is treated as GitHub Enterprise
+// with REST API at /api/v3/ and GraphQL API at /api/graphql.
+func New(baseURL, token string, opts ...Option) *Stats {
+	g := &Stats{
+		ctx:         context.Background(),
+		baseURL:     utils.Must(url.Parse(baseURL)),
+		token:       token,
+		userAliases: make(map[string][]string),
+		userByAlias: make(map[string]types.User),
+		counter:     make(map[types.User]types.PerLanguageCounter),
+		detector:    languages.New(),
+		rl:          rate.NewLimiter(50, 1),
+		filter:      &filter.Projects{},
+		pathFilter:  &filter.Paths{},
+		projects:    models.NewProjectCounters(),
+		progress: func(n int64) *progressbar.ProgressBar {
+			return progressbar.Default(n)
+		},
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	httpClient := &http.Client{
+		Transport: &limitedTransport{rl: g.rl, base: http.DefaultTransport},
+	}
+
+	if g.retries > 0 {
+		httpClient = httpretry.NewCustomClient(
+			httpClient,
+			httpretry.WithMaxRetryCount(g.retries),
+			httpretry.WithBackoffPolicy(httpretry.ExponentialBackoff(1*time.Second, 30*time.Second, 5*time.Second)),
+		)
+	}
+
+	g.client = github.NewClient(httpClient)
+
+	if token != "" {
+		g.client = g.client.WithAuthToken(token)
+	}
+
+	switch g.baseURL.Host {
+	case "", "github.com", "api.github.com":
+		g.graphqlURL = utils.Must(url.Parse("https://api.github.com/graphql"))
+	default:
+		g.client = utils.Must(g.client.WithEnterpriseURLs(baseURL, baseURL))
+		g.graphqlURL = &url.URL{Scheme: g.baseURL.Scheme, Host: g.baseURL.Host, Path: "/api/graphql"}
+	}
+
+	return g
+}
+
+func (s *Stats) count() {
+	s.excluded = models.MakeMapKindCounter(s.detector.Languages())
+
+	if err := s.getOwner(); err != nil {
+		s.se.Do(func() {
+			s.err = err
+		})
+		return
+	}
+
+	if err := s.getUsers(); err != nil {
+		s.se.Do(func() {
+			s.err = err
+		})
+		return
+	}
+
+	repos, err := s.getRepos()
+	if err != nil {
+		s.se.Do(func() {
+			s.err = err
+		})
+		return
+	}
+
+	repos = slices.DeleteFunc(repos, func(repo *github.Repository) bool {
+		reason := s.filter.Skip(filter.Project{
+			Path:       repo.GetFullName(),
+			Archived:   repo.GetArchived(),
+			Fork:       repo.GetFork(),
+			Visibility: repo.GetVisibility(),
+		})
+		if reason != "" {
+			logrus.Debugf("repository %s is skipped: %s", repo.GetFullName(), reason)
+		}
+
+		return reason != ""
+	})
+
+	var bar *progressbar.ProgressBar
+
+	if s.progress != nil {
+		bar = s.progress(int64(len(repos)))
+	}
+
+	projects := s.projectPool.Group()
+
+	for _, repo := range repos {
+		err := projects.Go(s.ctx, func() {
+			err := s.processRepo(repo)
+			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					s.se.Do(func() {
+						s.err = err
+					})
+					return
+				}
+				logrus.Error(err)
+				s.fail(repo, "", err)
+			} else {
+				s.complete(repo.GetFullName())
+			}
+
+			if bar != nil {
+				_ = bar.Add(1)
+			}
+		})
+		if err != nil {
+			break
+		}
+	}
+
+	projects.Wait()
+
+	if err := s.ctx.Err(); err != nil {
+		s.se.Do(func() {
+			s.err = err
+		})
+	}
+}
+
+// getOwner resolves whether the owner is an organization or a user.
+func (s *Stats) getOwner() error {
+	if s.owner == "" {
+		return nil
+	}
+
+	owner, _, err := s.client.Users.Get(s.ctx, s.owner)
+	if err != nil {
+		return errors.Join(fmt.Errorf("error getting owner %s", s.owner), err)
+	}
+
+	s.ownerType = owner.GetType()
+
+	return nil
+}
+
+func (s *Stats) getRepos() (repos []*github.Repository, err error) {
+	listOpts := github.ListOptions{
+		PerPage: 100,
+		Page:    1,
+	}
+
+	for {
+		var (
+			pp  []*github.Repository
+			res *github.Response
+		)
+
+		switch {
+		case s.owner == "":
+			pp, res, err = s.client.Repositories.ListByAuthenticatedUser(s.ctx, &github.RepositoryListByAuthenticatedUserOptions{
+				ListOptions: listOpts,
+			})
+		case s.ownerType == organizationType:
+			pp, res, err = s.client.Repositories.ListByOrg(s.ctx, s.owner, &github.RepositoryListByOrgOptions{
+				ListOptions: listOpts,
+			})
+		default:
+			pp, res, err = s.client.Repositories.ListByUser(s.ctx, s.owner, &github.RepositoryListByUserOptions{
+				ListOptions: listOpts,
+			})
+		}
+		if err != nil {
+			return nil, errors.Join(errors.New("error listing repositories"), err)
+		}
+
+		repos = append(repos, pp...)
+
+		if res.NextPage == 0 {
+			break
+		} else {
+			listOpts.Page = res.NextPage
+		}
+	}
+
+	return repos, nil
+}
+
+func (s *Stats) getUsers() error {
+	registered := make(map[string]struct{})
+
+	if s.ownerType == organizationType {
+		opts := &github.ListMembersOptions{
+			ListOptions: github.ListOptions{
+				PerPage: 100,
+				Page:    1,
+			},
+		}
+
+		for {
+			members, res, err := s.client.Organizations.ListMembers(s.ctx, s.owner, opts)
+			if err != nil {
+				return err
+			}
+
+			for _, member := range members {
+				if configured, ok := s.userByAlias[member.GetLogin()]; ok {
+					member.Email = github.String(configured.GetEmail())
+				} else if member, err = s.getProfile(member); err != nil {
+					return err
+				}
+
+				user := NewUser(member, s.userAliases[member.GetEmail()])
+				s.addUser(user)
+
+				registered[user.GetEmail()] = struct{}{}
+			}
+
+			if res.NextPage == 0 {
+				break
+			} else {
+				opts.Page = res.NextPage
+			}
+		}
+	}
+
+	for email, aliases := range s.userAliases {
+		if _, ok := registered[email]; ok {
+			continue
+		}
+
+		s.addUser(models.NewUser(email, aliases))
+	}
+
+	s.counter[models.DefaultUser] = models.MakeMapLanguageCounter(s.detector.Languages())
+
+	return nil
+}
+
+// getProfile returns the profile of the member with the public email, since members are listed without emails.
+func (s *Stats) getProfile(member *github.User) (*github.User, error) {
+	if member.GetEmail() != "" {
+		return member, nil
+	}
+
+	profile, _, err := s.client.Users.Get(s.ctx, member.GetLogin())
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("error getting user %s", member.GetLogin()), err)
+	}
+
+	return profile, nil
+}
+
+func (s *Stats) addUser(user types.User) {
+	s.counter[user] = models.MakeMapLanguageCounter(s.detector.Languages())
+
+	for _, alias := range user.GetAliases() {
+		s.userByAlias[alias] = user
+	}
+
+	s.userByAlias[user.GetEmail()] = user
+}
+
+func (s *Stats) processRepo(repo *github.Repository) error {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+
+	ref, err := s.getRef(repo)
+	if err != nil {
+		return err
+	}
+
+	if ref == "" {
+		logrus.Debugf("empty tree for repo %s", repo.GetFullName())
+		return nil
+	}
+
+	s.projects.Get(repo.GetFullName()).SetHead(ref)
+	s.facts.SetHead(repo.GetFullName(), ref)
+
+	if baseline, ok := s.baseline.Project(repo.GetFullName(), ref); ok {
+		logrus.Debugf("head of repo %s didn't move, using baseline", repo.GetFullName())
+		s.restore(repo, baseline)
+		return nil
+	}
+
+	config, err := s.getConfig(owner, name, ref)
+	if err != nil {
+		if errors.Is(err, s.ctx.Err()) {
+			return err
+		}
+		logrus.Warnf("ignoring %s of repo %s: %v", filter.ConfigFile, repo.GetFullName(), err)
+	}
+
+	attributes, err := s.getFile(owner, name, ref, languages.AttributesFile)
+	if err != nil {
+		if errors.Is(err, s.ctx.Err()) {
+			return err
+		}
+		logrus.Warnf("ignoring %s of repo %s: %v", languages.AttributesFile, repo.GetFullName(), err)
+	}
+
+	language := s.pathFilter.With(config).Language(s.detector.Resolver(nil))
+	classifier := languages.NewClassifier(attributes, s.include...)
+
+	if s.owners != nil {
+		if err := s.getCodeowners(repo, ref); err != nil {
+			logrus.Warnf("ignoring CODEOWNERS of repo %s: %v", repo.GetFullName(), err)
+		}
+	}
+
+	tree, _, err := s.client.Git.GetTree(s.ctx, owner, name, ref, true)
+	if err != nil {
+		var errRes *github.ErrorResponse
+		if errors.As(err, &errRes) && (errRes.Response.StatusCode == http.StatusNotFound || errRes.Response.StatusCode == http.StatusConflict) {
+			logrus.Debugf("empty tree for repo %s", repo.GetFullName())
+			return nil
+		}
+		return errors.Join(fmt.Errorf("error getting repo tree for repository %s", repo.GetFullName()), err)
+	}
+
+	if tree.GetTruncated() {
+		logrus.Warnf("tree for repository %s is truncated, some files are skipped", repo.GetFullName())
+	}
+
+	files := s.filePool.Group()
+	defer files.Wait()
+
+	for _, entry := range tree.Entries {
+		if entry.GetType() != "blob" {
+			continue
+		}
+
+		lang, ok := language(entry.GetPath(), func() ([]byte, error) {
+			data, _, err := s.client.Git.GetBlobRaw(s.ctx, owner, name, entry.GetSHA())
+			return data, err
+		})
+		if !ok {
+			continue
+		}
+
+		path, sha, kind := entry.GetPath(), entry.GetSHA(), classifier.Path(entry.GetPath())
+
+		err := files.Go(s.ctx, func() {
+			key := cache.Key{Project: repo.GetFullName(), Path: path, Blob: sha, Language: lang.Name()}
+
+			if kind == types.Source && s.cache != nil {
+				commit, err := s.getLastCommit(owner, name, ref, path)
+				if err != nil {
+					if !errors.Is(err, context.Canceled) {
+						logrus.Debugf("error getting last commit of file %s in repository %s: %v", path, repo.GetFullName(), err)
+						s.fail(repo, path, err)
+					}
+					return
+				}
+
+				key.Commit = commit
+			}
+
+			// files are sniffed before blame, so generated ones only cost a request of their contents
+			blame, ok := s.cache.Get(key)
+			if !ok || kind != types.Source {
+				data, _, err := s.client.Git.GetBlobRaw(s.ctx, owner, name, sha)
+				if err != nil {
+					if !errors.Is(err, context.Canceled) {
+						logrus.Debugf("error getting file %s in repository %s: %v", path, repo.GetFullName(), err)
+						s.fail(repo, path, err)
+					}
+					return
+				}
+
+				lines := strings.Split(string(data), "\n")
+
+				if kind == types.Source {
+					kind = classifier.Content(path, lines)
+				}
+
+				if kind != types.Source {
+					counted := models.CountLines(lang, lines)
+
+					s.addExcluded(repo, kind, lang, counted)
+					s.facts.Add(repo.GetFullName(), types.FileFacts{Path: path, Language: lang, Kind: kind, Lines: counted})
+
+					return
+				}
+
+				var ranges []types.BlameRange
+
+				blame, ranges, err = s.getBlame(s.ctx, owner, name, ref, path, lang)
+				if err != nil {
+					if !errors.Is(err, context.Canceled) {
+						logrus.Debugf("error gettings blame for file %s in repository %s: %v", path, repo.GetFullName(), err)
+						s.fail(repo, path, err)
+					}
+					return
+				}
+
+				if err := s.cache.Put(key, blame); err != nil {
+					logrus.Debugf("error caching blame for file %s in repository %s: %v", path, repo.GetFullName(), err)
+				}
+
+				s.facts.Add(repo.GetFullName(), types.FileFacts{
+					Path:     path,
+					Language: lang,
+					Kind:     classifier.Sniffed(path, blame.Generated),
+					Ranges:   ranges,
+				})
+			}
+
+			if kind := classifier.Sniffed(path, blame.Generated); kind != types.Source {
+				s.addExcluded(repo, kind, lang, blame.Lines())
+				return
+			}
+
+			for _, blameRange := range blame.Ranges {
+				if !blameRange.Date.IsZero() && !s.period.Contains(blameRange.Date) {
+					continue
+				}
+
+				user := s.getUser(blameRange.Email, blameRange.Login)
+
+				for kind, n := range blameRange.Lines {
+					s.add(repo, user, lang, kind, n)
+				}
+
+				s.owners.Get(repo.GetFullName()).Add(path, user, int(blameRange.Lines[types.Code]))
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	files.Wait()
+
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	if s.commits != nil {
+		return s.processCommits(repo, classifier.Language(language))
+	}
+
+	return nil
+}
+
+// getLastCommit returns the last commit changing the file at path in the history of ref.
+func (s *Stats) getLastCommit(owner, name, ref, path string) (string, error) {
+	commits, _, err := s.client.Repositories.ListCommits(s.ctx, owner, name, &github.CommitsListOptions{
+		SHA:         ref,
+		Path:        path,
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil || len(commits) == 0 {
+		return "", err
+	}
+
+	return commits[0].GetSHA(), nil
+}
+
+// getRef returns the head commit of the default branch of repo or the last commit on it before the configured time.
+// An empty ref means there are no commits before that time.
+func (s *Stats) getRef(repo *github.Repository) (string, error) {
+	if repo.GetDefaultBranch() == "" {
+		return "", nil
+	}
+
+	if s.at.IsZero() {
+		branch, _, err := s.client.Repositories.GetBranch(s.ctx, repo.GetOwner().GetLogin(), repo.GetName(), repo.GetDefaultBranch(), 1)
+		if err != nil {
+			var errRes *github.ErrorResponse
+			if errors.As(err, &errRes) && errRes.Response.StatusCode == http.StatusNotFound {
+				return "", nil
+			}
+			return "", errors.Join(fmt.Errorf("error getting default branch of repository %s", repo.GetFullName()), err)
+		}
+
+		return branch.GetCommit().GetSHA(), nil
+	}
+
+	commits, _, err := s.client.Repositories.ListCommits(s.ctx, repo.GetOwner().GetLogin(), repo.GetName(), &github.CommitsListOptions{
+		SHA:   repo.GetDefaultBranch(),
+		Until: s.at,
+		ListOptions: github.ListOptions{
+			PerPage: 1,
+			Page:    1,
+		},
+	})
+	if err != nil {
+		var errRes *github.ErrorResponse
+		if errors.As(err, &errRes) && errRes.Response.StatusCode == http.StatusConflict {
+			return "", nil
+		}
+		return "", errors.Join(fmt.Errorf("error getting commit before %s for repository %s", s.at, repo.GetFullName()), err)
+	}
+
+	if len(commits) == 0 {
+		return "", nil
+	}
+
+	return commits[0].GetSHA(), nil
+}
+
+// getConfig reads the repository configuration at ref, nil is returned if there is none.
+func (s *Stats) getConfig(owner, name, ref string) (*filter.Config, error) {
+	data, err := s.getFile(owner, name, ref, filter.ConfigFile)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	return filter.ParseConfig(data)
+}
+
+// getCodeowners reads the first CODEOWNERS file found in repo at ref.
+func (s *Stats) getCodeowners(repo *github.Repository, ref string) error {
+	for _, path := range owners.Locations {
+		data, err := s.getFile(repo.GetOwner().GetLogin(), repo.GetName(), ref, path)
+		if err != nil {
+			return err
+		}
+
+		if data != nil {
+			s.owners.Get(repo.GetFullName()).SetCodeowners(path, data)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// getFile reads the file at path and ref, nil is returned if there is no such file.
+func (s *Stats) getFile(owner, name, ref, path string) ([]byte, error) {
+	file, _, res, err := s.client.Repositories.GetContents(s.ctx, owner, name, path, &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if file == nil {
+		return nil, fmt.Errorf("%s is not a file", path)
+	}
+
+	data, err := file.GetContent()
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(data), nil
+}
+
+// getUser resolves the user by email or GitHub login falling back to the default user.
+func (s *Stats) getUser(email, login string) types.User {
+	if user, ok := s.userByAlias[email]; ok {
+		return user
+	}
+
+	if user, ok := s.userByAlias[login]; ok && login != "" {
+		return user
+	}
+
+	logrus.Debugf("unknown user %s, using default", email)
+
+	return models.DefaultUser
+}
+
+// add counts n lines of user in lang for totals and repo.
+func (s *Stats) add(repo *github.Repository, user types.User, lang types.Language, kind types.LineKind, n int64) {
+	s.counter[user].(models.MapLanguageCounter)[lang].Add(kind, n)
+	s.projects.Get(repo.GetFullName()).Add(user, lang, kind, n)
+}
+
+// addExcluded counts lines of a generated or vendored file of repo.
+func (s *Stats) addExcluded(repo *github.Repository, kind types.FileKind, lang types.Language, lines map[types.LineKind]int64) {
+	s.excluded.Add(kind, lang, lines)
+	s.projects.Get(repo.GetFullName()).AddExcluded(kind, lang, lines)
+}
+
+// restore counts lines of repo from its baseline instead of processing it.
+func (s *Stats) restore(repo *github.Repository, baseline *models.BaselineProject) {
+	baseline.Restore(
+		s.detector.Language,
+		func(email string, lang types.Language, kind types.LineKind, n int64) {
+			s.add(repo, s.getUser(email, ""), lang, kind, n)
+		},
+		func(kind types.FileKind, lang types.Language, lines map[types.LineKind]int64) {
+			s.addExcluded(repo, kind, lang, lines)
+		},
+	)
+}
+
+// complete writes lines of project to the checkpoint once it is processed,
+// projects with failed files are incomplete and processed again on resume.
+func (s *Stats) complete(project string) {
+	counter, ok := s.projects.Find(project)
+	if !ok || s.failures.Has(project) {
+		return
+	}
+
+	if err := s.checkpoint.Add(project, counter); err != nil {
+		logrus.Warnf("error writing checkpoint for %s: %v", project, err)
+	}
+}
+
+func (s *Stats) PerProject() map[string]types.ProjectCounter {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.projects.PerProject()
+}
+
+// fail records the failure of repo or the file at path in it.
+func (s *Stats) fail(repo *github.Repository, path string, err error) {
+	var status int
+
+	var errRes *github.ErrorResponse
+	var rateLimit *github.RateLimitError
+	var abuse *github.AbuseRateLimitError
+
+	switch {
+	case errors.As(err, &errRes) && errRes.Response != nil:
+		status = errRes.Response.StatusCode
+	case errors.As(err, &rateLimit) && rateLimit.Response != nil:
+		status = rateLimit.Response.StatusCode
+	case errors.As(err, &abuse) && abuse.Response != nil:
+		status = abuse.Response.StatusCode
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	}
+
+	failure := models.NewFailure(repo.GetFullName(), path, status, err)
+
+	if rateLimit != nil || abuse != nil {
+		failure.Class = types.RateLimited
+	}
+
+	s.failures.Add(failure)
+}
+
+func (s *Stats) Owners() map[string]types.FileOwnerCounter {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.owners.PerProject()
+}
+
+func (s *Stats) Facts() []types.ProjectFacts {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.facts.List()
+}
+
+func (s *Stats) Failures() []types.Failure {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.failures.List()
+}
+
+func (s *Stats) Excluded() map[types.FileKind]types.PerLanguageCounter {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.excluded.NonEmpty()
+}
+
+func (s *Stats) Commits() types.CommitCounter {
+	s.so.Do(s.count)
+
+	if s.err != nil || s.commits == nil {
+		return nil
+	}
+
+	return s.commits
+}
+
+func (s *Stats) PerUser() (res map[types.User]types.PerLanguageCounter) {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return
+	}
+
+	res = make(map[types.User]types.PerLanguageCounter)
+
+	for user, counter := range s.counter {
+		if counter.Total() == 0 {
+			continue
+		}
+
+		res[user] = counter
+	}
+
+	return
+}
+
+func (s *Stats) PerLanguage() (res map[types.Language]int) {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return
+	}
+
+	res = make(map[types.Language]int)
+
+	for _, langs := range s.counter {
+		for lang, n := range langs.PerLanguage() {
+			res[lang] += n
+		}
+	}
+
+	return
+}
+
+func (s *Stats) PerKind() map[types.LineKind]map[types.Language]int {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return models.MapUserCounter(s.counter).PerKind()
+}
+
+func (s *Stats) Total() (total int) {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return
+	}
+
+	for _, langs := range s.counter {
+		total += langs.Total()
+	}
+
+	return total
+}
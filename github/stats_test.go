@@ -0,0 +1,231 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/google/go-github/v66/github"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// mainGo is the contents of main.go in the fixture.
+const mainGo = "package main\n\nfunc main() {}\nvar x = 1\nvar y = 2\n"
+
+// generatedGo is main.go generated by a tool.
+const generatedGo = "// Code generated by stringer. DO NOT EDIT.\n\npackage main\n"
+
+// blameRange is a range of the blame fixture, author login is empty for commits not linked to an account.
+type blameRange struct {
+	start, end         int
+	authorEmail, login string
+}
+
+// newServer returns a stand-in for the REST and GraphQL API of GitHub Enterprise with the organization acme
+// of alice, who has a public email, and bob, who hasn't. The repository acme/app has main.go with content
+// at commit abc with lines committed by web-flow, as squash merges in the web UI are.
+func newServer(t *testing.T, content string, ranges []blameRange) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	reply := func(v any) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			if err := json.NewEncoder(w).Encode(v); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+
+	mux.HandleFunc("GET /api/v3/users/acme", reply(map[string]any{"login": "acme", "type": organizationType}))
+	mux.HandleFunc("GET /api/v3/orgs/acme/members", reply([]map[string]any{{"login": "alice"}, {"login": "bob"}}))
+	mux.HandleFunc("GET /api/v3/users/alice", reply(map[string]any{"login": "alice", "email": "alice@example.com"}))
+	mux.HandleFunc("GET /api/v3/users/bob", reply(map[string]any{"login": "bob"}))
+	mux.HandleFunc("GET /api/v3/orgs/acme/repos", reply([]map[string]any{{
+		"name":           "app",
+		"full_name":      "acme/app",
+		"owner":          map[string]any{"login": "acme"},
+		"default_branch": "main",
+	}}))
+	mux.HandleFunc("GET /api/v3/repos/acme/app/branches/main", reply(map[string]any{
+		"name":   "main",
+		"commit": map[string]any{"sha": "abc"},
+	}))
+	mux.HandleFunc("GET /api/v3/repos/acme/app/contents/", http.NotFound)
+	mux.HandleFunc("GET /api/v3/repos/acme/app/git/trees/abc", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("recursive") == "" {
+			t.Errorf("tree is requested without recursive")
+		}
+
+		reply(map[string]any{
+			"sha":  "abc",
+			"tree": []map[string]any{{"path": "main.go", "type": "blob", "sha": "blob"}},
+		})(w, r)
+	})
+	mux.HandleFunc("GET /api/v3/repos/acme/app/git/blobs/blob", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	})
+	mux.HandleFunc("POST /api/graphql", func(w http.ResponseWriter, r *http.Request) {
+		if ranges == nil {
+			t.Error("blame of a generated file is requested")
+		}
+
+		var req graphqlRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+
+		if req.Variables["ref"] != "abc" || req.Variables["path"] != "main.go" || req.Variables["file"] != "abc:main.go" {
+			t.Errorf("unexpected blame variables %v", req.Variables)
+		}
+
+		res := make([]map[string]any, 0, len(ranges))
+
+		for _, r := range ranges {
+			author := map[string]any{"name": r.authorEmail, "email": r.authorEmail, "user": nil}
+			if r.login != "" {
+				author["user"] = map[string]any{"login": r.login}
+			}
+
+			res = append(res, map[string]any{
+				"startingLine": r.start,
+				"endingLine":   r.end,
+				"commit": map[string]any{
+					"oid":           "c" + r.authorEmail,
+					"committedDate": "2024-05-01T00:00:00Z",
+					"author":        author,
+					"committer":     map[string]any{"name": "GitHub", "email": "noreply@github.com"},
+				},
+			})
+		}
+
+		reply(map[string]any{"data": map[string]any{"repository": map[string]any{
+			"commit": map[string]any{"blame": map[string]any{"ranges": res}},
+			"blob":   map[string]any{"text": content, "isBinary": false},
+		}}})(w, r)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// linesPerUser returns code lines per user email.
+func linesPerUser(s *Stats) map[string]int {
+	res := make(map[string]int)
+
+	for user, counter := range s.PerUser() {
+		res[user.GetEmail()] += counter.Total()
+	}
+
+	return res
+}
+
+func TestStatsCreditsAuthors(t *testing.T) {
+	srv := newServer(t, mainGo, []blameRange{
+		{1, 2, "alice@example.com", "alice"},
+		{3, 3, "1+bob@users.noreply.github.com", "bob"},
+		{4, 5, "eve@example.com", ""},
+	})
+
+	s := New(srv.URL, "token", WithOwner("acme"), WithProgress(false))
+
+	got := linesPerUser(s)
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{
+		"alice@example.com":           1,
+		"bob":                         1,
+		models.DefaultUser.GetEmail(): 2,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got lines %v, want %v", got, want)
+	}
+
+	for email, n := range want {
+		if got[email] != n {
+			t.Errorf("got %d lines of %s, want %d", got[email], email, n)
+		}
+	}
+
+	if failures := s.Failures(); len(failures) != 0 {
+		t.Errorf("unexpected failures %v", failures)
+	}
+}
+
+func TestStatsConfiguredUsers(t *testing.T) {
+	srv := newServer(t, mainGo, []blameRange{
+		{1, 3, "1+bob@users.noreply.github.com", "bob"},
+		{4, 5, "bob@example.com", ""},
+	})
+
+	s := New(srv.URL, "token",
+		WithOwner("acme"),
+		WithProgress(false),
+		WithUsers(models.NewUser("bob@example.com", []string{"bob"})),
+	)
+
+	got := linesPerUser(s)
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got["bob@example.com"] != 4 || len(got) != 1 {
+		t.Errorf("got lines %v, want 4 lines of bob@example.com", got)
+	}
+}
+
+func TestStatsSniffsBeforeBlame(t *testing.T) {
+	srv := newServer(t, generatedGo, nil)
+
+	s := New(srv.URL, "token", WithOwner("acme"), WithProgress(false))
+
+	if got := linesPerUser(s); len(got) != 0 {
+		t.Errorf("got lines %v of a generated file", got)
+	}
+
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	excluded := s.Excluded()
+
+	if counter, ok := excluded[types.Generated]; !ok || len(excluded) != 1 || counter.Total() != 1 {
+		t.Errorf("got excluded lines %v, want 1 generated code line", excluded)
+	}
+}
+
+func TestGetFileBlameNotFound(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/graphql", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"repository":null},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a Repository"}]}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := New(srv.URL, "token")
+
+	_, err := s.getFileBlame(context.Background(), "acme", "app", "abc", "main.go")
+	if err == nil || !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got error %v, want %v", err, ErrNotFound)
+	}
+
+	s.fail(&github.Repository{FullName: github.String("acme/app")}, "main.go", err)
+
+	failures := s.failures.List()
+	if len(failures) != 1 || failures[0].Status != http.StatusNotFound || failures[0].Class != types.NotFound {
+		t.Errorf("got failures %v, want a not found failure", failures)
+	}
+}
@@ -0,0 +1,49 @@
+package github
+
+import (
+	"github.com/google/go-github/v66/github"
+	"sync"
+)
+
+type (
+	User struct {
+		*github.User
+		aliases []string
+		so      sync.Once
+	}
+)
+
+func NewUser(user *github.User, aliases []string) *User {
+	return &User{User: user, aliases: aliases}
+}
+
+func (u *User) GetAliases() []string {
+	u.so.Do(func() {
+		addLogin := true
+
+		for _, alias := range u.aliases {
+			if alias == u.GetLogin() {
+				addLogin = false
+			}
+		}
+
+		if addLogin && u.GetLogin() != "" {
+			u.aliases = append(u.aliases, u.GetLogin())
+		}
+	})
+
+	return u.aliases
+}
+
+// GetEmail returns the public email of the user or the login if the email is hidden.
+func (u *User) GetEmail() string {
+	if email := u.User.GetEmail(); email != "" {
+		return email
+	}
+
+	return u.GetLogin()
+}
+
+func (u *User) GetUsername() string {
+	return u.GetLogin()
+}
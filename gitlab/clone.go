@@ -0,0 +1,226 @@
+package gitlab
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/filter"
+	"github.com/gaarutyunov/gitstat/languages"
+	"github.com/gaarutyunov/gitstat/local"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/owners"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/sirupsen/logrus"
+	"github.com/xanzy/go-gitlab"
+	"os"
+	"path/filepath"
+)
+
+var errCloneFailed = errors.New("clone failed")
+
+// processClone clones the default branch of repo and counts blame locally.
+// Errors wrapping errCloneFailed mean nothing was counted and the API can be used instead.
+func (s *Stats) processClone(repo *gitlab.Project, resolver types.LanguageResolver) error {
+	if repo.EmptyRepo || repo.DefaultBranch == "" {
+		logrus.Debugf("empty tree for repo %s", repo.PathWithNamespace)
+		return nil
+	}
+
+	r, cleanup, err := s.cloneRepo(repo)
+	if err != nil {
+		return errors.Join(errCloneFailed, fmt.Errorf("error cloning project %s", repo.PathWithNamespace), err)
+	}
+	defer cleanup()
+
+	ref, err := r.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, repo.DefaultBranch), true)
+	if err != nil {
+		return errors.Join(errCloneFailed, fmt.Errorf("error resolving default branch of project %s", repo.PathWithNamespace), err)
+	}
+
+	commit, boundary, err := local.Shallow(r, ref.Hash())
+	if err != nil {
+		return errors.Join(errCloneFailed, fmt.Errorf("error getting commit %s of project %s", ref.Hash(), repo.PathWithNamespace), err)
+	}
+
+	if !s.at.IsZero() {
+		commit, err = local.CommitAt(commit, s.at)
+		if err != nil {
+			return errors.Join(fmt.Errorf("error getting commit before %s of project %s", s.at, repo.PathWithNamespace), err)
+		}
+
+		if commit == nil {
+			if len(boundary) != 0 {
+				logrus.Warnf("no commits before %s in the last %d commits of repo %s", s.at, s.cloneDepth, repo.PathWithNamespace)
+			} else {
+				logrus.Debugf("no commits before %s in repo %s", s.at, repo.PathWithNamespace)
+			}
+			return nil
+		}
+	}
+
+	// go-git blame only reports authors, committers are resolved to match the API blame
+	committer := local.Committers(r)
+
+	config, err := local.ReadConfig(commit)
+	if err != nil {
+		logrus.Warnf("ignoring %s of repo %s: %v", filter.ConfigFile, repo.PathWithNamespace, err)
+	}
+
+	attributes, err := local.ReadFile(commit, languages.AttributesFile)
+	if err != nil {
+		logrus.Warnf("ignoring %s of repo %s: %v", languages.AttributesFile, repo.PathWithNamespace, err)
+	}
+
+	language := s.pathFilter.With(config).Language(resolver)
+	classifier := languages.NewClassifier(attributes, s.include...)
+
+	if s.owners != nil {
+		for _, path := range owners.Locations {
+			if data, err := local.ReadFile(commit, path); err == nil && data != nil {
+				s.owners.Get(repo.PathWithNamespace).SetCodeowners(path, data)
+				break
+			}
+		}
+	}
+
+	fileOwners := s.owners.Get(repo.PathWithNamespace)
+
+	err = local.Blame(s.ctx, commit, language, classifier, func(path string, lang types.Language, blame *git.BlameResult) {
+		scanner := models.NewLineScanner(lang)
+		kinds := make([]types.LineKind, 0, len(blame.Lines))
+
+		for _, line := range blame.Lines {
+			kind := scanner.Scan(line.Text)
+			kinds = append(kinds, kind)
+
+			// lines of the boundary of a shallow clone may be changed by older commits,
+			// so they are credited to nobody and their date is unknown
+			if boundary.Contains(line.Hash) {
+				if s.period.IsZero() {
+					s.add(repo, models.DefaultUser, lang, kind, 1)
+				}
+				continue
+			}
+
+			signature := committer(line.Hash)
+
+			if !s.period.Contains(signature.When) {
+				continue
+			}
+
+			user := s.getUser(signature.Email)
+
+			s.add(repo, user, lang, kind, 1)
+
+			if kind == types.Code {
+				fileOwners.Add(path, user, 1)
+			}
+		}
+
+		if s.facts != nil {
+			ranges := local.BlameRanges(blame, kinds, committer)
+
+			for i, r := range ranges {
+				if boundary.Contains(plumbing.NewHash(r.Commit)) {
+					ranges[i] = types.BlameRange{Commit: r.Commit, Lines: r.Lines}
+				}
+			}
+
+			s.facts.Add(repo.PathWithNamespace, types.FileFacts{
+				Path:     path,
+				Language: lang,
+				Kind:     types.Source,
+				Ranges:   ranges,
+			})
+		}
+	}, func(path string, lang types.Language, kind types.FileKind, lines []string) {
+		counted := models.CountLines(lang, lines)
+
+		s.addExcluded(repo, kind, lang, counted)
+		s.facts.Add(repo.PathWithNamespace, types.FileFacts{Path: path, Language: lang, Kind: kind, Lines: counted})
+	}, func(path string, err error) {
+		s.fail(repo, path, nil, err)
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.commits != nil {
+		return s.processCloneCommits(repo, commit, boundary, classifier.Language(language))
+	}
+
+	return nil
+}
+
+// cloneRepo clones repo into the clone directory or updates the existing clone.
+// Without a clone directory the repository is cloned into a temporary directory removed by cleanup.
+func (s *Stats) cloneRepo(repo *gitlab.Project) (r *git.Repository, cleanup func(), err error) {
+	cleanup = func() {}
+
+	var dir string
+
+	if s.cloneDir == "" {
+		dir, err = os.MkdirTemp("", "gitstat-*")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		cleanup = func() {
+			if err := os.RemoveAll(dir); err != nil {
+				logrus.Warnf("error removing clone of project %s: %v", repo.PathWithNamespace, err)
+			}
+		}
+	} else {
+		dir = filepath.Join(s.cloneDir, filepath.FromSlash(repo.PathWithNamespace))
+	}
+
+	auth := &http.BasicAuth{Username: "oauth2", Password: s.token}
+	branch := plumbing.NewBranchReferenceName(repo.DefaultBranch)
+
+	r, err = git.PlainOpen(dir)
+	if err == nil {
+		err = r.FetchContext(s.ctx, &git.FetchOptions{
+			RefSpecs: []config.RefSpec{
+				config.RefSpec(fmt.Sprintf("+%s:%s", branch, plumbing.NewRemoteReferenceName(git.DefaultRemoteName, repo.DefaultBranch))),
+			},
+			Depth: s.cloneDepth,
+			Auth:  auth,
+			Tags:  git.NoTags,
+			Force: true,
+		})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			cleanup()
+			return nil, nil, err
+		}
+
+		return r, cleanup, nil
+	}
+
+	if !errors.Is(err, git.ErrRepositoryNotExists) {
+		cleanup()
+		return nil, nil, err
+	}
+
+	r, err = git.PlainCloneContext(s.ctx, dir, true, &git.CloneOptions{
+		URL:           repo.HTTPURLToRepo,
+		Auth:          auth,
+		ReferenceName: branch,
+		SingleBranch:  true,
+		Depth:         s.cloneDepth,
+		Tags:          git.NoTags,
+	})
+	if err != nil {
+		cleanup()
+
+		if s.cloneDir != "" {
+			_ = os.RemoveAll(dir)
+		}
+
+		return nil, nil, err
+	}
+
+	return r, cleanup, nil
+}
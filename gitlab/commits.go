@@ -0,0 +1,195 @@
+package gitlab
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/local"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+	"github.com/xanzy/go-gitlab"
+	"strings"
+)
+
+// processCommits counts changes of non-merge commits on the default branch of repo.
+func (s *Stats) processCommits(repo *gitlab.Project, language types.LanguageResolver) error {
+	opts := &gitlab.ListCommitsOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+			Page:    1,
+		},
+		RefName:   gitlab.Ptr(repo.DefaultBranch),
+		WithStats: gitlab.Ptr(true),
+	}
+
+	if !s.period.Since.IsZero() {
+		opts.Since = gitlab.Ptr(s.period.Since)
+	}
+
+	if !s.period.Until.IsZero() {
+		opts.Until = gitlab.Ptr(s.period.Until)
+	}
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		default:
+		}
+
+		commits, res, err := s.client.Commits.ListCommits(repo.ID, opts, gitlab.WithContext(s.ctx))
+		if err != nil {
+			if errors.Is(err, gitlab.ErrNotFound) {
+				logrus.Debugf("empty history for repo %s", repo.PathWithNamespace)
+				return nil
+			}
+			return errors.Join(fmt.Errorf("error listing commits for project %s", repo.PathWithNamespace), err)
+		}
+
+		for _, commit := range commits {
+			if len(commit.ParentIDs) > 1 {
+				continue
+			}
+
+			var total types.CommitCount
+
+			if commit.Stats != nil {
+				total = types.CommitCount{Additions: commit.Stats.Additions, Deletions: commit.Stats.Deletions}
+			}
+
+			var changes map[types.Language]types.CommitCount
+
+			if s.commitLanguages && total != (types.CommitCount{}) {
+				var diffRes *gitlab.Response
+
+				changes, diffRes, err = s.getCommitChanges(repo, commit.ID, total, language)
+				if err != nil {
+					if s.ctx.Err() != nil {
+						return s.ctx.Err()
+					}
+					logrus.Debugf("error getting diff of commit %s in repository %s: %v", commit.ID, repo.PathWithNamespace, err)
+					s.fail(repo, "", diffRes, errors.Join(fmt.Errorf("error getting diff of commit %s", commit.ID), err))
+				}
+			}
+
+			s.commits.Add(repo.PathWithNamespace, s.getUser(commit.AuthorEmail), total, changes)
+		}
+
+		if res.CurrentPage == res.TotalPages || res.NextPage == 0 {
+			break
+		} else {
+			opts.Page = res.NextPage
+		}
+	}
+
+	return nil
+}
+
+// getCommitChanges counts added and deleted lines per language in the diff of commit.
+// GitLab truncates and collapses large diffs, so the diff is compared with the total of the commit
+// and an error is returned instead of lines missing from it. The response of a failed call is returned with its error.
+func (s *Stats) getCommitChanges(repo *gitlab.Project, sha string, total types.CommitCount, language types.LanguageResolver) (map[types.Language]types.CommitCount, *gitlab.Response, error) {
+	opts := &gitlab.GetCommitDiffOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+			Page:    1,
+		},
+	}
+
+	var counted types.CommitCount
+
+	changes := make(map[types.Language]types.CommitCount)
+
+	for {
+		diffs, res, err := s.client.Commits.GetCommitDiff(repo.ID, sha, opts, gitlab.WithContext(s.ctx))
+		if err != nil {
+			return nil, res, err
+		}
+
+		for _, diff := range diffs {
+			var n types.CommitCount
+
+			for _, line := range strings.Split(diff.Diff, "\n") {
+				switch {
+				case strings.HasPrefix(line, "+"):
+					n.Additions++
+				case strings.HasPrefix(line, "-"):
+					n.Deletions++
+				}
+			}
+
+			counted.Additions += n.Additions
+			counted.Deletions += n.Deletions
+
+			path := diff.NewPath
+			if diff.DeletedFile {
+				path = diff.OldPath
+			}
+
+			lang, ok := language(path, nil)
+			if !ok {
+				continue
+			}
+
+			c := changes[lang]
+			c.Additions += n.Additions
+			c.Deletions += n.Deletions
+			changes[lang] = c
+		}
+
+		if res.CurrentPage == res.TotalPages || res.NextPage == 0 {
+			break
+		} else {
+			opts.Page = res.NextPage
+		}
+	}
+
+	if counted != total {
+		return nil, nil, fmt.Errorf("diff is truncated or collapsed, it has +%d -%d lines of +%d -%d", counted.Additions, counted.Deletions, total.Additions, total.Deletions)
+	}
+
+	return changes, nil, nil
+}
+
+// processCloneCommits counts changes of non-merge commits in the history of a cloned repo.
+func (s *Stats) processCloneCommits(repo *gitlab.Project, head *object.Commit, boundary local.Boundary, language types.LanguageResolver) error {
+	iter := object.NewCommitPreorderIter(head, nil, nil)
+	defer iter.Close()
+
+	return iter.ForEach(func(commit *object.Commit) error {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		default:
+		}
+
+		// changes of boundary commits of a shallow clone can't be told apart from older lines
+		if commit.NumParents() > 1 || boundary.Contains(commit.Hash) || !s.period.Contains(commit.Committer.When) {
+			return nil
+		}
+
+		stats, err := commit.Stats()
+		if err != nil {
+			logrus.Debugf("error getting stats of commit %s in repository %s: %v", commit.Hash, repo.PathWithNamespace, err)
+			return nil
+		}
+
+		total, changes := local.CommitChanges(stats, language)
+
+		s.commits.Add(repo.PathWithNamespace, s.getUser(commit.Author.Email), total, changes)
+
+		return nil
+	})
+}
+
+func (s *Stats) getUser(email string) types.User {
+	user, ok := s.userByAlias[email]
+	if !ok {
+		logrus.Debugf("unknown user %s, using default", email)
+
+		return models.DefaultUser
+	}
+
+	return user
+}
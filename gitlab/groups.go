@@ -0,0 +1,54 @@
+package gitlab
+
+import (
+	"errors"
+	"fmt"
+	"github.com/xanzy/go-gitlab"
+)
+
+// getGroupRepos sends projects of groups including subgroups once remembering all groups every project belongs to.
+func (s *Stats) getGroupRepos(projectCh chan<- *gitlab.Project) error {
+	var projects []*gitlab.Project
+
+	for _, group := range s.groups {
+		opts := &gitlab.ListGroupProjectsOptions{
+			ListOptions: gitlab.ListOptions{
+				PerPage: 100,
+				Page:    1,
+			},
+			IncludeSubGroups: gitlab.Ptr(true),
+			Search:           &s.query,
+		}
+
+		if s.filter.ExcludeArchived {
+			opts.Archived = gitlab.Ptr(false)
+		}
+
+		for {
+			pp, res, err := s.client.Groups.ListGroupProjects(group, opts, gitlab.WithContext(s.ctx))
+			if err != nil {
+				return errors.Join(fmt.Errorf("error listing projects of group %s", group), err)
+			}
+
+			for _, project := range pp {
+				if _, ok := s.projectGroups[project.ID]; !ok {
+					projects = append(projects, project)
+				}
+
+				s.projectGroups[project.ID] = append(s.projectGroups[project.ID], group)
+			}
+
+			if res.CurrentPage == res.TotalPages || res.NextPage == 0 {
+				break
+			} else {
+				opts.Page = res.NextPage
+			}
+		}
+	}
+
+	for _, project := range projects {
+		projectCh <- project
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package gitlab
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/xanzy/go-gitlab"
+)
+
+// processMergeRequests counts merge requests of repo with their approvals and comments.
+// Errors of the API are recorded as failures of repo, only cancellation is returned.
+func (s *Stats) processMergeRequests(repo *gitlab.Project) error {
+	if repo.MergeRequestsAccessLevel == gitlab.DisabledAccessControl {
+		return nil
+	}
+
+	opts := &gitlab.ListProjectMergeRequestsOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+			Page:    1,
+		},
+		State: gitlab.Ptr("all"),
+	}
+
+	if !s.period.Since.IsZero() {
+		opts.CreatedAfter = gitlab.Ptr(s.period.Since)
+	}
+
+	if !s.period.Until.IsZero() {
+		opts.CreatedBefore = gitlab.Ptr(s.period.Until)
+	}
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		default:
+		}
+
+		mergeRequests, res, err := s.client.MergeRequests.ListProjectMergeRequests(repo.ID, opts, gitlab.WithContext(s.ctx))
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return s.ctx.Err()
+			}
+			logrus.Debugf("error listing merge requests for repo %s: %v", repo.PathWithNamespace, err)
+			s.fail(repo, "", res, errors.Join(fmt.Errorf("error listing merge requests for project %s", repo.PathWithNamespace), err))
+			return nil
+		}
+
+		for _, mr := range mergeRequests {
+			if res, err := s.processMergeRequest(repo, mr); err != nil {
+				if s.ctx.Err() != nil {
+					return s.ctx.Err()
+				}
+				logrus.Debugf("error processing merge request !%d in repository %s: %v", mr.IID, repo.PathWithNamespace, err)
+				s.fail(repo, "", res, errors.Join(fmt.Errorf("error processing merge request !%d", mr.IID), err))
+			}
+		}
+
+		if res.CurrentPage == res.TotalPages || res.NextPage == 0 {
+			break
+		} else {
+			opts.Page = res.NextPage
+		}
+	}
+
+	return nil
+}
+
+// processMergeRequest counts mr with its approvals and comments, the response of a failed call is returned with its error.
+func (s *Stats) processMergeRequest(repo *gitlab.Project, mr *gitlab.MergeRequest) (*gitlab.Response, error) {
+	var author string
+
+	if mr.Author != nil {
+		author = mr.Author.Username
+	}
+
+	user := s.getUser(author)
+
+	s.mergeRequests.Opened(user)
+
+	switch mr.State {
+	case "merged":
+		if mr.MergedAt != nil && mr.CreatedAt != nil {
+			s.mergeRequests.Merged(user, mr.MergedAt.Sub(*mr.CreatedAt))
+		}
+	case "closed":
+		s.mergeRequests.Closed(user)
+	}
+
+	approvals, res, err := s.client.MergeRequestApprovals.GetConfiguration(repo.ID, mr.IID, gitlab.WithContext(s.ctx))
+	if err != nil {
+		return res, errors.Join(errors.New("error getting approvals"), err)
+	}
+
+	for _, approver := range approvals.ApprovedBy {
+		if approver.User == nil {
+			continue
+		}
+
+		s.mergeRequests.Approved(s.getUser(approver.User.Username))
+	}
+
+	opts := &gitlab.ListMergeRequestNotesOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+			Page:    1,
+		},
+	}
+
+	for {
+		notes, res, err := s.client.Notes.ListMergeRequestNotes(repo.ID, mr.IID, opts, gitlab.WithContext(s.ctx))
+		if err != nil {
+			return res, errors.Join(errors.New("error listing notes"), err)
+		}
+
+		for _, note := range notes {
+			if note.System || note.Author.Username == author {
+				continue
+			}
+
+			if note.CreatedAt != nil && !s.period.Contains(*note.CreatedAt) {
+				continue
+			}
+
+			s.mergeRequests.Commented(s.getUser(note.Author.Username))
+		}
+
+		if res.CurrentPage == res.TotalPages || res.NextPage == 0 {
+			break
+		} else {
+			opts.Page = res.NextPage
+		}
+	}
+
+	return nil, nil
+}
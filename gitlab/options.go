@@ -2,6 +2,10 @@ package gitlab
 
 import (
 	"context"
+	"github.com/gaarutyunov/gitstat/cache"
+	"github.com/gaarutyunov/gitstat/filter"
+	"github.com/gaarutyunov/gitstat/languages"
+	"github.com/gaarutyunov/gitstat/models"
 	"github.com/gaarutyunov/gitstat/types"
 	"github.com/gaarutyunov/gitstat/utils"
 	"github.com/go-git/go-git/v5/plumbing/transport/client"
@@ -9,18 +13,13 @@ import (
 	"github.com/schollz/progressbar/v3"
 	"github.com/ybbus/httpretry"
 	"golang.org/x/time/rate"
-	"regexp"
 	"time"
 )
 
+// WithLanguages overrides or extends the built-in languages detected in repositories.
 func WithLanguages(langs ...types.Language) Option {
 	return func(g *Stats) {
-		g.langs = langs
-		for _, lang := range g.langs {
-			for _, ext := range lang.Ext() {
-				g.langByExt[ext] = lang
-			}
-		}
+		g.detector = languages.New(langs...)
 	}
 }
 
@@ -73,14 +72,158 @@ func SetRetries(n int) {
 	mx.Unlock()
 }
 
+// WithRetries sets the number of retries of API requests failing with rate limits or server errors.
+func WithRetries(n int) Option {
+	return func(g *Stats) {
+		g.retries = n
+	}
+}
+
 func WithQuery(s string) Option {
 	return func(stats *Stats) {
 		stats.query = s
 	}
 }
 
-func WithExclude(pattern string) Option {
+// WithFilter sets the filter applied to projects before they are processed.
+func WithFilter(f *filter.Projects) Option {
+	return func(g *Stats) {
+		if f != nil {
+			g.filter = f
+		}
+	}
+}
+
+// WithPaths sets the filter of files by their paths inside repositories.
+func WithPaths(f *filter.Paths) Option {
+	return func(g *Stats) {
+		if f != nil {
+			g.pathFilter = f
+		}
+	}
+}
+
+// WithCache reuses blame of files unchanged since it was stored in c.
+func WithCache(c *cache.Cache) Option {
+	return func(g *Stats) {
+		g.cache = c
+	}
+}
+
+// WithIncluded counts lines of generated or vendored files like the ones of regular files.
+func WithIncluded(kinds ...types.FileKind) Option {
+	return func(g *Stats) {
+		g.include = kinds
+	}
+}
+
+// WithClone enables cloning of projects into dir to compute blame locally instead of requesting it per file.
+// Projects are cloned into temporary directories if dir is empty. A positive depth makes shallow clones,
+// lines last changed by the oldest commits of a shallow clone may be older, so they are counted as other lines
+// and skipped if a date range is set, commits are counted only within the clone.
+func WithClone(clone bool, dir string, depth int) Option {
+	return func(g *Stats) {
+		g.clone = clone
+		g.cloneDir = dir
+		g.cloneDepth = depth
+	}
+}
+
+// WithCommits enables counting of commits on the default branch of every project.
+func WithCommits(commits bool) Option {
+	return func(g *Stats) {
+		if commits {
+			g.commits = models.NewCommitCounter()
+		} else {
+			g.commits = nil
+		}
+	}
+}
+
+// WithCommitLanguages splits added and deleted lines of commits per language,
+// which needs a diff request per commit unless projects are cloned.
+func WithCommitLanguages(split bool) Option {
+	return func(g *Stats) {
+		g.commitLanguages = split
+	}
+}
+
+// WithMergeRequests enables counting of merge requests, approvals and comments of every project.
+func WithMergeRequests(mergeRequests bool) Option {
+	return func(g *Stats) {
+		if mergeRequests {
+			g.mergeRequests = models.NewMergeRequestCounter()
+		} else {
+			g.mergeRequests = nil
+		}
+	}
+}
+
+// WithSince restricts statistics to lines, commits and merge requests since t.
+func WithSince(t time.Time) Option {
+	return func(g *Stats) {
+		g.period.Since = t
+	}
+}
+
+// WithUntil restricts statistics to lines, commits and merge requests before t.
+func WithUntil(t time.Time) Option {
+	return func(g *Stats) {
+		g.period.Until = t
+	}
+}
+
+// WithAt computes line statistics at the last commit before t instead of the head of the default branch.
+func WithAt(t time.Time) Option {
+	return func(g *Stats) {
+		g.at = t
+	}
+}
+
+// WithGroups restricts projects to the groups and their subgroups and breaks statistics down per group.
+func WithGroups(groups ...string) Option {
 	return func(g *Stats) {
-		g.exclude = utils.Must(regexp.Compile(pattern))
+		g.groups = append(g.groups, groups...)
+	}
+}
+
+// WithBaseline restores projects which head didn't move since the baseline report instead of processing them.
+func WithBaseline(b *models.Baseline) Option {
+	return func(g *Stats) {
+		g.baseline = b
+	}
+}
+
+// WithCheckpoint writes every processed project to the checkpoint.
+func WithCheckpoint(c *models.Checkpoint) Option {
+	return func(g *Stats) {
+		g.checkpoint = c
+	}
+}
+
+// WithConcurrency bounds the number of projects and files processed at once, 0 means no bound.
+// The file bound is shared by all projects.
+func WithConcurrency(projects, files int) Option {
+	return func(g *Stats) {
+		g.projectPool = utils.NewPool(projects)
+		g.filePool = utils.NewPool(files)
+	}
+}
+
+// WithOwners counts lines per file of every project to report their owners.
+func WithOwners(enabled bool) Option {
+	return func(g *Stats) {
+		if enabled {
+			g.owners = models.NewOwnerCounters()
+		}
+	}
+}
+
+// WithFacts keeps the blame ranges of every file, the blame cache should be disabled since it doesn't store commits.
+func WithFacts(enabled bool) Option {
+	return func(g *Stats) {
+		if enabled {
+			g.facts = models.NewFacts()
+		}
 	}
 }
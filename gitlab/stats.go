@@ -4,77 +4,70 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/gaarutyunov/gitstat/cache"
+	"github.com/gaarutyunov/gitstat/filter"
+	"github.com/gaarutyunov/gitstat/languages"
 	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/owners"
 	"github.com/gaarutyunov/gitstat/types"
 	"github.com/gaarutyunov/gitstat/utils"
 	"github.com/schollz/progressbar/v3"
 	"github.com/sirupsen/logrus"
 	"github.com/xanzy/go-gitlab"
 	"golang.org/x/time/rate"
+	"net/http"
 	"net/url"
-	"path/filepath"
-	"regexp"
 	"strings"
 	"sync"
-	"sync/atomic"
+	"time"
 )
 
 type (
 	Stats struct {
-		ctx         context.Context
-		baseURL     *url.URL
-		token       string
-		query       string
-		client      *gitlab.Client
-		so          sync.Once
-		langs       []types.Language
-		langByExt   map[string]types.Language
-		userAliases map[string][]string
-		userByAlias map[string]types.User
-		counter     map[types.User]types.PerLanguageCounter
-		se          sync.Once
-		err         error
-		sem         chan struct{}
-		retries     int
-		rl          *rate.Limiter
-		progress    func(n int64) *progressbar.ProgressBar
-		exclude     *regexp.Regexp
-	}
-
-	MapLanguageCounter map[types.Language]*atomic.Int64
+		ctx             context.Context
+		baseURL         *url.URL
+		token           string
+		query           string
+		client          *gitlab.Client
+		so              sync.Once
+		detector        *languages.Detector
+		userAliases     map[string][]string
+		userByAlias     map[string]types.User
+		counter         map[types.User]types.PerLanguageCounter
+		se              sync.Once
+		err             error
+		projectPool     *utils.Pool
+		filePool        *utils.Pool
+		failures        models.Failures
+		owners          *models.OwnerCounters
+		facts           *models.Facts
+		retries         int
+		rl              *rate.Limiter
+		progress        func(n int64) *progressbar.ProgressBar
+		filter          *filter.Projects
+		cache           *cache.Cache
+		include         []types.FileKind
+		excluded        models.MapKindCounter
+		projects        *models.ProjectCounters
+		baseline        *models.Baseline
+		checkpoint      *models.Checkpoint
+		pathFilter      *filter.Paths
+		clone           bool
+		cloneDir        string
+		cloneDepth      int
+		commits         *models.CommitCounter
+		commitLanguages bool
+		mergeRequests   *models.MergeRequestCounter
+		period          utils.Period
+		at              time.Time
+		groups          []string
+		projectGroups   map[int][]string
+		groupCounter    map[string]models.MapUserCounter
+	}
 )
 
-var defaultUser = models.NewUser("other", nil)
 var mx sync.Mutex
 
-func makeMapLanguageCounter(keys []types.Language) types.PerLanguageCounter {
-	m := make(MapLanguageCounter, len(keys))
-
-	for _, key := range keys {
-		m[key] = &atomic.Int64{}
-	}
-
-	return m
-}
-
-func (m MapLanguageCounter) PerLanguage() (res map[types.Language]int) {
-	res = make(map[types.Language]int, len(m))
-
-	for language, counter := range m {
-		res[language] = int(counter.Load())
-	}
-
-	return
-}
-
-func (m MapLanguageCounter) Total() (total int) {
-	for _, counter := range m {
-		total += int(counter.Load())
-	}
-
-	return
-}
-
 func (s *Stats) Err() error {
 	return s.err
 }
@@ -83,14 +76,20 @@ type Option func(*Stats)
 
 func New(baseURL, token string, opts ...Option) *Stats {
 	g := &Stats{
-		ctx:         context.Background(),
-		baseURL:     utils.Must(url.Parse(baseURL)),
-		token:       token,
-		userAliases: make(map[string][]string),
-		userByAlias: make(map[string]types.User),
-		counter:     make(map[types.User]types.PerLanguageCounter),
-		langByExt:   make(map[string]types.Language),
-		rl:          rate.NewLimiter(50, 1),
+		ctx:           context.Background(),
+		baseURL:       utils.Must(url.Parse(baseURL)),
+		token:         token,
+		userAliases:   make(map[string][]string),
+		userByAlias:   make(map[string]types.User),
+		counter:       make(map[types.User]types.PerLanguageCounter),
+		detector:      languages.New(),
+		retries:       5,
+		rl:            rate.NewLimiter(50, 1),
+		filter:        &filter.Projects{},
+		pathFilter:    &filter.Paths{},
+		projects:      models.NewProjectCounters(),
+		projectGroups: make(map[int][]string),
+		groupCounter:  make(map[string]models.MapUserCounter),
 		progress: func(n int64) *progressbar.ProgressBar {
 			return progressbar.Default(n)
 		},
@@ -104,129 +103,104 @@ func New(baseURL, token string, opts ...Option) *Stats {
 		token,
 		gitlab.WithBaseURL(baseURL),
 		gitlab.WithCustomLimiter(g.rl),
+		gitlab.WithCustomRetryMax(g.retries),
 	))
 
 	return g
 }
 
 func (s *Stats) count() {
-	projectCh := make(chan *gitlab.Project)
-	var totalRepos, processedRepos atomic.Int64
+	if err := s.getUsers(); err != nil {
+		s.se.Do(func() {
+			s.err = err
+		})
+		return
+	}
 
-	var incCh chan struct{}
-	var decCh chan struct{}
+	projectCh := make(chan *gitlab.Project)
 	doneCh := make(chan struct{})
 
+	var repos []*gitlab.Project
+
 	go func() {
+		defer close(doneCh)
+
 		for repo := range projectCh {
-			if s.exclude != nil && s.exclude.MatchString(repo.PathWithNamespace) {
-				logrus.Debugf("repository %s doesn't match pattern %s, skipping", repo.PathWithNamespace, s.exclude)
+			if reason := s.filter.Skip(filter.Project{
+				Path:       repo.PathWithNamespace,
+				Archived:   repo.Archived,
+				Fork:       repo.ForkedFromProject != nil,
+				Visibility: string(repo.Visibility),
+			}); reason != "" {
+				logrus.Debugf("repository %s is skipped: %s", repo.PathWithNamespace, reason)
 				continue
 			}
 
-			totalRepos.Add(1)
-
-			go func() {
-				select {
-				case <-s.ctx.Done():
-					return
-				default:
-				}
-
-				err := s.processRepo(repo)
-				if err != nil {
-					if errors.Is(err, context.Canceled) {
-						s.se.Do(func() {
-							s.err = err
-						})
-						doneCh <- struct{}{}
-						close(doneCh)
-						return
-					}
-					logrus.Error(err)
-					s.decProcessed(&totalRepos, decCh)
-					return
-				}
-
-				s.incProcessed(&processedRepos, incCh)
-
-				if processedRepos.CompareAndSwap(totalRepos.Load(), 0) {
-					doneCh <- struct{}{}
-					close(doneCh)
-				}
-			}()
+			repos = append(repos, repo)
 		}
 	}()
 
-	if err := s.getUsers(); err != nil {
-		s.se.Do(func() {
-			s.err = err
-		})
-		return
-	}
+	err := s.getRepos(projectCh)
+	close(projectCh)
+	<-doneCh
 
-	if err := s.getRepos(projectCh); err != nil {
+	if err != nil {
 		s.se.Do(func() {
 			s.err = err
 		})
-		close(projectCh)
 		return
-	} else {
-		close(projectCh)
 	}
 
-	if s.progress != nil {
-		incCh = make(chan struct{})
-		decCh = make(chan struct{})
+	var bar *progressbar.ProgressBar
 
-		defer func() {
-			close(incCh)
-			close(decCh)
-		}()
+	if s.progress != nil {
+		bar = s.progress(int64(len(repos)))
+	}
 
-		bar := s.progress(totalRepos.Load())
+	projects := s.projectPool.Group()
 
-		go func() {
-			for range incCh {
-				select {
-				case <-s.ctx.Done():
+	for _, repo := range repos {
+		err := projects.Go(s.ctx, func() {
+			err := s.processRepo(repo)
+			if err == nil && s.mergeRequests != nil {
+				err = s.processMergeRequests(repo)
+			}
+			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					s.se.Do(func() {
+						s.err = err
+					})
 					return
-				default:
 				}
-				bar.Add(1)
+				logrus.Error(err)
+				s.fail(repo, "", nil, err)
+			} else {
+				s.complete(repo.PathWithNamespace)
 			}
-		}()
 
-		go func() {
-			for range decCh {
-				select {
-				case <-s.ctx.Done():
-					return
-				default:
-				}
-				bar.ChangeMax64(totalRepos.Load())
+			if bar != nil {
+				_ = bar.Add(1)
 			}
-		}()
+		})
+		if err != nil {
+			break
+		}
 	}
 
-	<-doneCh
-}
+	projects.Wait()
 
-func (s *Stats) incProcessed(counter *atomic.Int64, ch chan<- struct{}) {
-	counter.Add(1)
-	if ch != nil {
-		ch <- struct{}{}
+	if err := s.ctx.Err(); err != nil {
+		s.se.Do(func() {
+			s.err = err
+		})
 	}
 }
 
-func (s *Stats) decProcessed(counter *atomic.Int64, ch chan<- struct{}) {
-	counter.Add(-1)
-	if ch != nil {
-		ch <- struct{}{}
+func (s *Stats) getRepos(projectCh chan<- *gitlab.Project) error {
+	if len(s.groups) != 0 {
+		return s.getGroupRepos(projectCh)
 	}
-}
 
-func (s *Stats) getRepos(projectCh chan<- *gitlab.Project) error {
 	opts := &gitlab.ListProjectsOptions{
 		ListOptions: gitlab.ListOptions{
 			PerPage: 100,
@@ -235,6 +209,10 @@ func (s *Stats) getRepos(projectCh chan<- *gitlab.Project) error {
 		Search: &s.query,
 	}
 
+	if s.filter.ExcludeArchived {
+		opts.Archived = gitlab.Ptr(false)
+	}
+
 	for {
 		pp, res, err := s.client.Projects.ListProjects(opts, gitlab.WithContext(s.ctx))
 		if err != nil {
@@ -271,7 +249,7 @@ func (s *Stats) getUsers() error {
 
 		for _, u := range users {
 			user := NewUser(u, s.userAliases[u.Email])
-			s.counter[user] = makeMapLanguageCounter(s.langs)
+			s.counter[user] = models.MakeMapLanguageCounter(s.detector.Languages())
 
 			for _, alias := range user.GetAliases() {
 				s.userByAlias[alias] = user
@@ -287,22 +265,87 @@ func (s *Stats) getUsers() error {
 		}
 	}
 
-	s.counter[defaultUser] = makeMapLanguageCounter(s.langs)
+	s.counter[models.DefaultUser] = models.MakeMapLanguageCounter(s.detector.Languages())
+	s.excluded = models.MakeMapKindCounter(s.detector.Languages())
+
+	for _, group := range s.groups {
+		s.groupCounter[group] = make(models.MapUserCounter, len(s.counter))
+
+		for user := range s.counter {
+			s.groupCounter[group][user] = models.MakeMapLanguageCounter(s.detector.Languages())
+		}
+	}
 
 	return nil
 }
 
 func (s *Stats) processRepo(repo *gitlab.Project) error {
+	ref, err := s.getRef(repo)
+	if err != nil {
+		return err
+	}
+
+	if ref == "" {
+		logrus.Debugf("empty tree for repo %s", repo.PathWithNamespace)
+		return nil
+	}
+
+	s.projects.Get(repo.PathWithNamespace).SetHead(ref)
+	s.facts.SetHead(repo.PathWithNamespace, ref)
+
+	if baseline, ok := s.baseline.Project(repo.PathWithNamespace, ref); ok {
+		logrus.Debugf("head of repo %s didn't move, using baseline", repo.PathWithNamespace)
+		s.restore(repo, baseline)
+		return nil
+	}
+
+	resolver := s.detector.Resolver(s.getLanguages(repo))
+
+	if s.clone {
+		err := s.processClone(repo, resolver)
+		if !errors.Is(err, errCloneFailed) {
+			return err
+		}
+
+		logrus.Warnf("falling back to blame API: %v", err)
+	}
+
+	config, err := s.getConfig(repo, ref)
+	if err != nil {
+		if errors.Is(err, s.ctx.Err()) {
+			return err
+		}
+		logrus.Warnf("ignoring %s of repo %s: %v", filter.ConfigFile, repo.PathWithNamespace, err)
+	}
+
+	attributes, err := s.getFile(repo, ref, languages.AttributesFile)
+	if err != nil {
+		if errors.Is(err, s.ctx.Err()) {
+			return err
+		}
+		logrus.Warnf("ignoring %s of repo %s: %v", languages.AttributesFile, repo.PathWithNamespace, err)
+	}
+
+	language := s.pathFilter.With(config).Language(resolver)
+	classifier := languages.NewClassifier(attributes, s.include...)
+
+	if s.owners != nil {
+		if err := s.getCodeowners(repo, ref); err != nil {
+			logrus.Warnf("ignoring CODEOWNERS of repo %s: %v", repo.PathWithNamespace, err)
+		}
+	}
+
 	opts := &gitlab.ListTreeOptions{
 		ListOptions: gitlab.ListOptions{
 			PerPage: 100,
 			Page:    1,
 		},
 		Recursive: gitlab.Ptr(true),
+		Ref:       gitlab.Ptr(ref),
 	}
 
-	var totalFiles, processedFiles atomic.Int64
-	doneCh := make(chan struct{})
+	files := s.filePool.Group()
+	defer files.Wait()
 
 	for {
 		select {
@@ -331,95 +374,463 @@ func (s *Stats) processRepo(repo *gitlab.Project) error {
 				continue
 			}
 
-			ext := filepath.Ext(node.Path)
-
-			lang, ok := s.langByExt[ext]
+			lang, ok := language(node.Path, func() ([]byte, error) {
+				return s.getFile(repo, ref, node.Path)
+			})
 			if !ok {
-				logrus.Debugf("skipping file with extension %s", ext)
 				continue
 			}
 
-			totalFiles.Add(1)
+			path, blob, kind := node.Path, node.ID, classifier.Path(node.Path)
 
-			select {
-			case <-s.ctx.Done():
-				return s.ctx.Err()
-			default:
+			err := files.Go(s.ctx, func() {
+				err := s.processFile(repo, ref, path, blob, lang, kind, classifier)
+				if err != nil && s.ctx.Err() == nil {
+					logrus.Debugf("error processing file %s in repository %s: %v", path, repo.PathWithNamespace, err)
+					s.fail(repo, path, nil, err)
+				}
+			})
+			if err != nil {
+				return err
 			}
+		}
 
-			go func(path string, lang types.Language) {
-				select {
-				case <-s.ctx.Done():
-					return
-				default:
-				}
+		if res.CurrentPage == res.TotalPages {
+			break
+		} else {
+			opts.Page = res.NextPage
+		}
+	}
 
-				blame, _, err := s.client.RepositoryFiles.GetFileBlame(
-					repo.ID,
-					path,
-					&gitlab.GetFileBlameOptions{
-						Ref: gitlab.Ptr(repo.DefaultBranch),
-					},
-					gitlab.WithContext(s.ctx),
-				)
-				if err != nil && !errors.Is(err, context.Canceled) {
-					logrus.Debugf("error gettings blame for file %s in repository %s: %v", path, repo.PathWithNamespace, err)
-					return
-				}
+	files.Wait()
 
-				select {
-				case <-s.ctx.Done():
-					return
-				default:
-				}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
 
-				for _, blameRange := range blame {
-					user, ok := s.userByAlias[blameRange.Commit.CommitterEmail]
-					if !ok {
-						logrus.Debugf("unknown user %s, using default", blameRange.Commit.CommitterEmail)
+	return s.processCommitsIfEnabled(repo, classifier.Language(language))
+}
 
-						user = defaultUser
-					}
+// getRef returns the head commit of the default branch of repo or the last commit on it before the configured time.
+// An empty ref means there are no commits before that time.
+func (s *Stats) getRef(repo *gitlab.Project) (string, error) {
+	if repo.EmptyRepo || repo.DefaultBranch == "" {
+		return "", nil
+	}
 
-					var linesCount int64
-					for _, line := range blameRange.Lines {
-						if strings.TrimSpace(line) != "" {
-							linesCount++
-						}
-					}
+	if s.at.IsZero() {
+		branch, _, err := s.client.Branches.GetBranch(repo.ID, repo.DefaultBranch, gitlab.WithContext(s.ctx))
+		if err != nil {
+			if errors.Is(err, gitlab.ErrNotFound) {
+				return "", nil
+			}
+			return "", errors.Join(fmt.Errorf("error getting default branch of project %s", repo.PathWithNamespace), err)
+		}
 
-					s.counter[user].(MapLanguageCounter)[lang].Add(linesCount)
-				}
+		if branch.Commit == nil {
+			return "", nil
+		}
 
-				processedFiles.Add(1)
+		return branch.Commit.ID, nil
+	}
 
-				if processedFiles.CompareAndSwap(totalFiles.Load(), 0) {
-					doneCh <- struct{}{}
-					close(doneCh)
-				}
-			}(node.Path, lang)
+	commits, _, err := s.client.Commits.ListCommits(repo.ID, &gitlab.ListCommitsOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 1,
+			Page:    1,
+		},
+		RefName:     gitlab.Ptr(repo.DefaultBranch),
+		Until:       gitlab.Ptr(s.at),
+		FirstParent: gitlab.Ptr(true),
+	}, gitlab.WithContext(s.ctx))
+	if err != nil {
+		if errors.Is(err, gitlab.ErrNotFound) {
+			return "", nil
 		}
+		return "", errors.Join(fmt.Errorf("error getting commit before %s for project %s", s.at, repo.PathWithNamespace), err)
+	}
 
-		if res.CurrentPage == res.TotalPages {
-			break
-		} else {
-			opts.Page = res.NextPage
+	if len(commits) == 0 {
+		return "", nil
+	}
+
+	return commits[0].ID, nil
+}
+
+// getLanguages returns shares of languages in repo used to resolve ambiguous extensions.
+func (s *Stats) getLanguages(repo *gitlab.Project) map[string]float32 {
+	langs, _, err := s.client.Projects.GetProjectLanguages(repo.ID, gitlab.WithContext(s.ctx))
+	if err != nil {
+		logrus.Debugf("error getting languages of project %s: %v", repo.PathWithNamespace, err)
+		return nil
+	}
+
+	if langs == nil {
+		return nil
+	}
+
+	return *langs
+}
+
+// processFile counts lines of the file at path blamed at ref, lines of generated and vendored files
+// are counted separately without blame. Files are sniffed before blame, so generated ones only cost
+// a request of their contents.
+func (s *Stats) processFile(repo *gitlab.Project, ref, path, blob string, lang types.Language, kind types.FileKind, classifier *languages.Classifier) error {
+	key := cache.Key{Project: repo.PathWithNamespace, Path: path, Blob: blob, Language: lang.Name()}
+
+	if kind == types.Source && s.cache != nil {
+		commit, err := s.getLastCommit(repo, ref, path)
+		if err != nil {
+			return err
 		}
+
+		key.Commit = commit
 	}
 
-	if processedFiles.CompareAndSwap(totalFiles.Load(), 0) {
-		close(doneCh)
+	blame, ok := s.cache.Get(key)
+	if !ok || kind != types.Source {
+		data, err := s.getFile(repo, ref, path)
+		if err != nil {
+			return err
+		}
+
+		lines := strings.Split(string(data), "\n")
+
+		if kind == types.Source {
+			kind = classifier.Content(path, lines)
+		}
+
+		if kind != types.Source {
+			counted := models.CountLines(lang, lines)
+
+			s.addExcluded(repo, kind, lang, counted)
+			s.facts.Add(repo.PathWithNamespace, types.FileFacts{Path: path, Language: lang, Kind: kind, Lines: counted})
+
+			return nil
+		}
+
+		var ranges []types.BlameRange
+
+		blame, ranges, err = s.getBlame(repo, ref, path, lang)
+		if err != nil {
+			return err
+		}
+
+		if err := s.cache.Put(key, blame); err != nil {
+			logrus.Debugf("error caching blame for file %s in repository %s: %v", path, repo.PathWithNamespace, err)
+		}
+
+		s.facts.Add(repo.PathWithNamespace, types.FileFacts{
+			Path:     path,
+			Language: lang,
+			Kind:     classifier.Sniffed(path, blame.Generated),
+			Ranges:   ranges,
+		})
+	}
+
+	if kind := classifier.Sniffed(path, blame.Generated); kind != types.Source {
+		s.addExcluded(repo, kind, lang, blame.Lines())
 		return nil
 	}
 
-	for {
-		select {
-		case <-s.ctx.Done():
-			return s.ctx.Err()
-		case <-doneCh:
+	for _, blameRange := range blame.Ranges {
+		if !blameRange.Date.IsZero() && !s.period.Contains(blameRange.Date) {
+			continue
+		}
+
+		user, ok := s.userByAlias[blameRange.Email]
+		if !ok {
+			logrus.Debugf("unknown user %s, using default", blameRange.Email)
+
+			user = models.DefaultUser
+		}
+
+		for kind, n := range blameRange.Lines {
+			s.add(repo, user, lang, kind, n)
+		}
+
+		s.owners.Get(repo.PathWithNamespace).Add(path, user, int(blameRange.Lines[types.Code]))
+	}
+
+	return nil
+}
+
+// getLastCommit returns the last commit changing the file at path in the history of ref
+// with a HEAD request of the file, it is empty if GitLab doesn't report it.
+func (s *Stats) getLastCommit(repo *gitlab.Project, ref, path string) (string, error) {
+	file, _, err := s.client.RepositoryFiles.GetFileMetaData(repo.ID, path, &gitlab.GetFileMetaDataOptions{Ref: gitlab.Ptr(ref)}, gitlab.WithContext(s.ctx))
+	if err != nil {
+		return "", err
+	}
+
+	return file.LastCommitID, nil
+}
+
+// getBlame requests the blame of the file at path and ref aggregating lines by committer,
+// ranges are the blame ranges as they are returned.
+func (s *Stats) getBlame(repo *gitlab.Project, ref, path string, lang types.Language) (blame *cache.Blame, ranges []types.BlameRange, err error) {
+	blameRanges, _, err := s.client.RepositoryFiles.GetFileBlame(
+		repo.ID,
+		path,
+		&gitlab.GetFileBlameOptions{
+			Ref: gitlab.Ptr(ref),
+		},
+		gitlab.WithContext(s.ctx),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var lines []string
+	for _, blameRange := range blameRanges {
+		lines = append(lines, blameRange.Lines...)
+	}
+
+	blame = &cache.Blame{Generated: languages.Sniff(path, lines)}
+	scanner := models.NewLineScanner(lang)
+
+	for _, blameRange := range blameRanges {
+		var date time.Time
+		if blameRange.Commit.CommittedDate != nil {
+			date = *blameRange.Commit.CommittedDate
+		}
+
+		r := types.BlameRange{
+			Commit:    blameRange.Commit.ID,
+			Author:    types.Person{Name: blameRange.Commit.AuthorName, Email: blameRange.Commit.AuthorEmail},
+			Committer: types.Person{Name: blameRange.Commit.CommitterName, Email: blameRange.Commit.CommitterEmail},
+			Date:      date,
+			Lines:     make(map[types.LineKind]int64, 3),
+		}
+
+		// every line is scanned to track block comments spanning ranges
+		for _, line := range blameRange.Lines {
+			kind := scanner.Scan(line)
+
+			blame.Add(blameRange.Commit.CommitterEmail, "", date, kind, 1)
+			r.Lines[kind]++
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	return blame, ranges, nil
+}
+
+// getConfig reads the repository configuration at ref, nil is returned if there is none.
+func (s *Stats) getConfig(repo *gitlab.Project, ref string) (*filter.Config, error) {
+	data, err := s.getFile(repo, ref, filter.ConfigFile)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	return filter.ParseConfig(data)
+}
+
+// getCodeowners reads the first CODEOWNERS file found in repo at ref.
+func (s *Stats) getCodeowners(repo *gitlab.Project, ref string) error {
+	for _, path := range owners.Locations {
+		data, err := s.getFile(repo, ref, path)
+		if err != nil {
+			return err
+		}
+
+		if data != nil {
+			s.owners.Get(repo.PathWithNamespace).SetCodeowners(path, data)
 			return nil
 		}
 	}
+
+	return nil
+}
+
+// getFile reads the file at path and ref, nil is returned if there is no such file.
+func (s *Stats) getFile(repo *gitlab.Project, ref, path string) ([]byte, error) {
+	data, _, err := s.client.RepositoryFiles.GetRawFile(
+		repo.ID,
+		path,
+		&gitlab.GetRawFileOptions{Ref: gitlab.Ptr(ref)},
+		gitlab.WithContext(s.ctx),
+	)
+	if err != nil {
+		if errors.Is(err, gitlab.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s *Stats) processCommitsIfEnabled(repo *gitlab.Project, language types.LanguageResolver) error {
+	if s.commits == nil {
+		return nil
+	}
+
+	return s.processCommits(repo, language)
+}
+
+// fail records the failure of repo or the file at path in it, res is the response of the failed call if any.
+// GitLab answers 429 when rate limited and reports the remaining requests in the RateLimit-Remaining header.
+func (s *Stats) fail(repo *gitlab.Project, path string, res *gitlab.Response, err error) {
+	var response *http.Response
+
+	var errRes *gitlab.ErrorResponse
+	if res != nil && res.Response != nil {
+		response = res.Response
+	} else if errors.As(err, &errRes) {
+		response = errRes.Response
+	}
+
+	var status int
+
+	if response != nil {
+		status = response.StatusCode
+	} else if errors.Is(err, gitlab.ErrNotFound) {
+		status = http.StatusNotFound
+	}
+
+	failure := models.NewFailure(repo.PathWithNamespace, path, status, err)
+
+	if response != nil && response.Header.Get("RateLimit-Remaining") == "0" {
+		failure.Class = types.RateLimited
+	}
+
+	s.failures.Add(failure)
+}
+
+func (s *Stats) Owners() map[string]types.FileOwnerCounter {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.owners.PerProject()
+}
+
+func (s *Stats) Facts() []types.ProjectFacts {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.facts.List()
+}
+
+func (s *Stats) Failures() []types.Failure {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.failures.List()
+}
+
+func (s *Stats) Excluded() map[types.FileKind]types.PerLanguageCounter {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.excluded.NonEmpty()
+}
+
+func (s *Stats) Commits() types.CommitCounter {
+	s.so.Do(s.count)
+
+	if s.err != nil || s.commits == nil {
+		return nil
+	}
+
+	return s.commits
+}
+
+func (s *Stats) MergeRequests() types.MergeRequestCounter {
+	s.so.Do(s.count)
+
+	if s.err != nil || s.mergeRequests == nil {
+		return nil
+	}
+
+	return s.mergeRequests
+}
+
+// add counts n lines of user in lang for totals and every group of repo.
+func (s *Stats) add(repo *gitlab.Project, user types.User, lang types.Language, kind types.LineKind, n int64) {
+	s.counter[user].(models.MapLanguageCounter)[lang].Add(kind, n)
+
+	for _, group := range s.projectGroups[repo.ID] {
+		s.groupCounter[group][user].(models.MapLanguageCounter)[lang].Add(kind, n)
+	}
+
+	s.projects.Get(repo.PathWithNamespace).Add(user, lang, kind, n)
+}
+
+// addExcluded counts lines of a generated or vendored file of repo.
+func (s *Stats) addExcluded(repo *gitlab.Project, kind types.FileKind, lang types.Language, lines map[types.LineKind]int64) {
+	s.excluded.Add(kind, lang, lines)
+	s.projects.Get(repo.PathWithNamespace).AddExcluded(kind, lang, lines)
+}
+
+// restore counts lines of repo from its baseline instead of processing it.
+func (s *Stats) restore(repo *gitlab.Project, baseline *models.BaselineProject) {
+	baseline.Restore(
+		s.detector.Language,
+		func(email string, lang types.Language, kind types.LineKind, n int64) {
+			user, ok := s.userByAlias[email]
+			if !ok {
+				user = models.DefaultUser
+			}
+
+			s.add(repo, user, lang, kind, n)
+		},
+		func(kind types.FileKind, lang types.Language, lines map[types.LineKind]int64) {
+			s.addExcluded(repo, kind, lang, lines)
+		},
+	)
+}
+
+// complete writes lines of project to the checkpoint once it is processed,
+// projects with failed files are incomplete and processed again on resume.
+func (s *Stats) complete(project string) {
+	counter, ok := s.projects.Find(project)
+	if !ok || s.failures.Has(project) {
+		return
+	}
+
+	if err := s.checkpoint.Add(project, counter); err != nil {
+		logrus.Warnf("error writing checkpoint for %s: %v", project, err)
+	}
+}
+
+func (s *Stats) PerProject() map[string]types.ProjectCounter {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.projects.PerProject()
+}
+
+func (s *Stats) PerGroup() (res map[string]types.LineCounter) {
+	s.so.Do(s.count)
+
+	if s.err != nil || len(s.groups) == 0 {
+		return
+	}
+
+	res = make(map[string]types.LineCounter, len(s.groupCounter))
+
+	for group, counter := range s.groupCounter {
+		res[group] = counter
+	}
+
+	return
 }
 
 func (s *Stats) PerUser() (res map[types.User]types.PerLanguageCounter) {
@@ -449,17 +860,27 @@ func (s *Stats) PerLanguage() (res map[types.Language]int) {
 		return
 	}
 
-	res = make(map[types.Language]int, len(s.langs))
+	res = make(map[types.Language]int)
 
 	for _, langs := range s.counter {
-		for lang, counter := range langs.(MapLanguageCounter) {
-			res[lang] += int(counter.Load())
+		for lang, n := range langs.PerLanguage() {
+			res[lang] += n
 		}
 	}
 
 	return
 }
 
+func (s *Stats) PerKind() map[types.LineKind]map[types.Language]int {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return models.MapUserCounter(s.counter).PerKind()
+}
+
 func (s *Stats) Total() (total int) {
 	s.so.Do(s.count)
 
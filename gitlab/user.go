@@ -45,3 +45,7 @@ func (u *User) GetAliases() []string {
 func (u *User) GetEmail() string {
 	return u.Email
 }
+
+func (u *User) GetUsername() string {
+	return u.Username
+}
@@ -0,0 +1,180 @@
+package languages
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/sirupsen/logrus"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// AttributesFile is the file with linguist attributes read from the root of repositories.
+const AttributesFile = ".gitattributes"
+
+// headerLines is the number of lines at the beginning of files searched for generated markers.
+const headerLines = 5
+
+var (
+	vendoredPaths = regexp.MustCompile(`(^|/)(vendor|vendors|node_modules|bower_components|third[_-]?party|3rdparty|Godeps/_workspace|Pods|Carthage/Checkouts|\.yarn)/`)
+
+	generatedPaths = regexp.MustCompile(`(\.pb\.(go|cc|h|swift)|_pb2(_grpc)?\.pyi?|_pb\.(js|d\.ts)|\.pb\.gw\.go|\.(min|g|freezed)\.\w+|-min\.(js|css)|\.designer\.cs|(^|/)zz_generated\.[^/]+)$|(^|/)(package-lock\.json|yarn\.lock|pnpm-lock\.yaml|Cargo\.lock|Gemfile\.lock|composer\.lock|poetry\.lock|Pipfile\.lock|go\.sum)$`)
+
+	// generatedHeader matches the conventional markers of generated files, see https://go.dev/s/generatedcode,
+	// not comments merely mentioning generated values
+	generatedHeader = regexp.MustCompile(`(?i)(code generated .*do not edit|@generated\b|<auto-generated)`)
+)
+
+type (
+	// Classifier detects generated and vendored files of a repository.
+	Classifier struct {
+		rules   []attributeRule
+		include []types.FileKind
+	}
+
+	attributeRule struct {
+		pattern string
+		kind    types.FileKind
+		set     bool
+	}
+)
+
+// NewClassifier creates a classifier of files in a repository with the gitattributes file contents,
+// files of kinds in include are classified as types.Source.
+func NewClassifier(gitattributes []byte, include ...types.FileKind) *Classifier {
+	c := &Classifier{include: include}
+
+	scanner := bufio.NewScanner(bytes.NewReader(gitattributes))
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		pattern := strings.TrimPrefix(fields[0], "/")
+		if !strings.Contains(fields[0], "/") {
+			pattern = "**/" + pattern
+		}
+
+		if !doublestar.ValidatePattern(pattern) {
+			continue
+		}
+
+		for _, attr := range fields[1:] {
+			var rule attributeRule
+
+			switch attr {
+			case "linguist-generated", "linguist-generated=true":
+				rule = attributeRule{kind: types.Generated, set: true}
+			case "-linguist-generated", "linguist-generated=false":
+				rule = attributeRule{kind: types.Generated}
+			case "linguist-vendored", "linguist-vendored=true":
+				rule = attributeRule{kind: types.Vendored, set: true}
+			case "-linguist-vendored", "linguist-vendored=false":
+				rule = attributeRule{kind: types.Vendored}
+			default:
+				continue
+			}
+
+			rule.pattern = pattern
+			c.rules = append(c.rules, rule)
+		}
+	}
+
+	return c
+}
+
+// Path classifies the file at file by the repository attributes and path heuristics.
+func (c *Classifier) Path(file string) types.FileKind {
+	generated, vendored := generatedPaths.MatchString(file), vendoredPaths.MatchString(file)
+
+	// the last matching line wins like in gitattributes
+	for _, rule := range c.rules {
+		if !doublestar.MatchUnvalidated(rule.pattern, file) {
+			continue
+		}
+
+		switch rule.kind {
+		case types.Generated:
+			generated = rule.set
+		case types.Vendored:
+			vendored = rule.set
+		}
+	}
+
+	switch {
+	case vendored && !c.included(types.Vendored):
+		return types.Vendored
+	case generated && !c.included(types.Generated):
+		return types.Generated
+	default:
+		return types.Source
+	}
+}
+
+// Content classifies the file at file by its lines.
+func (c *Classifier) Content(file string, lines []string) types.FileKind {
+	return c.Sniffed(file, Sniff(file, lines))
+}
+
+// Sniffed classifies the file at file which contents were sniffed as generated or not.
+func (c *Classifier) Sniffed(file string, generated bool) types.FileKind {
+	if !generated || c.included(types.Generated) || c.explicit(file, types.Generated) {
+		return types.Source
+	}
+
+	return types.Generated
+}
+
+// Sniff reports whether the first lines of file mark generated code or the file is minified.
+func Sniff(file string, lines []string) bool {
+	for i, line := range lines {
+		if i == headerLines {
+			break
+		}
+
+		if generatedHeader.MatchString(line) {
+			return true
+		}
+	}
+
+	// minified sources have very long lines
+	ext := path.Ext(file)
+
+	return (ext == ".js" || ext == ".css") && slices.ContainsFunc(lines, func(line string) bool {
+		return len(line) > 1000
+	})
+}
+
+func (c *Classifier) included(kind types.FileKind) bool {
+	return slices.Contains(c.include, kind)
+}
+
+// explicit reports whether the attributes explicitly unset kind for file.
+func (c *Classifier) explicit(file string, kind types.FileKind) bool {
+	unset := false
+
+	for _, rule := range c.rules {
+		if rule.kind == kind && doublestar.MatchUnvalidated(rule.pattern, file) {
+			unset = !rule.set
+		}
+	}
+
+	return unset
+}
+
+// Language wraps the language resolver of files to skip generated and vendored paths.
+func (c *Classifier) Language(lang types.LanguageResolver) types.LanguageResolver {
+	return func(path string, content func() ([]byte, error)) (types.Language, bool) {
+		if kind := c.Path(path); kind != types.Source {
+			logrus.Debugf("skipping %s file %s", kind, path)
+			return nil, false
+		}
+
+		return lang(path, content)
+	}
+}
@@ -0,0 +1,28 @@
+package languages
+
+import "testing"
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		lines []string
+		want  bool
+	}{
+		{"go", "a.go", []string{"// Code generated by stringer; DO NOT EDIT.", "", "package a"}, true},
+		{"at generated", "a.js", []string{"/**", " * @generated SignedSource<<abc>>", " */"}, true},
+		{"auto-generated tag", "a.cs", []string{"// <auto-generated>", "//     This code was generated by a tool."}, true},
+		{"auto-generated value", "a.go", []string{"package a", "", "// the id is auto-generated by the DB"}, false},
+		{"generated mention", "a.py", []string{"# values are generated by the factory, do not edit them by hand"}, false},
+		{"after header", "a.go", []string{"package a", "", "", "", "", "// Code generated by hand. DO NOT EDIT."}, false},
+		{"minified", "a.min.css", []string{string(make([]byte, 1001))}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sniff(tt.file, tt.lines); got != tt.want {
+				t.Errorf("Sniff(%q) = %v, want %v", tt.lines, got, tt.want)
+			}
+		})
+	}
+}
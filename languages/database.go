@@ -0,0 +1,108 @@
+package languages
+
+import "github.com/gaarutyunov/gitstat/types"
+
+// Definition describes how files of a language are recognized.
+type Definition struct {
+	Name string
+	// Extensions include the leading dot, ambiguous extensions are resolved
+	// by the repository languages or the first language defining them.
+	Extensions []string
+	// Filenames are exact base names of files without a meaningful extension.
+	Filenames []string
+	// Interpreters are the programs named in the shebang line without a version suffix.
+	Interpreters []string
+	Comments     types.Comments
+}
+
+var (
+	doubleQuote = types.Quote{Delimiter: `"`}
+	singleQuote = types.Quote{Delimiter: "'"}
+	quotes      = []types.Quote{doubleQuote, singleQuote}
+
+	cComments          = types.Comments{Line: []string{"//"}, Block: [][2]string{{"/*", "*/"}}, Strings: quotes}
+	goComments         = types.Comments{Line: []string{"//"}, Block: [][2]string{{"/*", "*/"}}, Strings: append([]types.Quote{{Delimiter: "`", Raw: true, Multiline: true}}, quotes...)}
+	jsComments         = types.Comments{Line: []string{"//"}, Block: [][2]string{{"/*", "*/"}}, Strings: append([]types.Quote{{Delimiter: "`", Multiline: true}}, quotes...)}
+	rustComments       = types.Comments{Line: []string{"//"}, Block: [][2]string{{"/*", "*/"}}, Strings: []types.Quote{doubleQuote}}
+	lineComments       = types.Comments{Line: []string{"//"}, Strings: []types.Quote{doubleQuote}}
+	blockComments      = types.Comments{Block: [][2]string{{"/*", "*/"}}, Strings: quotes}
+	hashComments       = types.Comments{Line: []string{"#"}, Strings: quotes}
+	semicolonComments  = types.Comments{Line: []string{";"}, Strings: []types.Quote{doubleQuote}}
+	percentComments    = types.Comments{Line: []string{"%"}, Strings: []types.Quote{doubleQuote}}
+	batchComments      = types.Comments{Line: []string{"REM", "rem", "::"}}
+	coffeeComments     = types.Comments{Line: []string{"#"}, Block: [][2]string{{"###", "###"}}, Strings: quotes}
+	fortranComments    = types.Comments{Line: []string{"!"}, Strings: quotes}
+	fsharpComments     = types.Comments{Line: []string{"//"}, Block: [][2]string{{"(*", "*)"}}, Strings: []types.Quote{doubleQuote}}
+	haskellComments    = types.Comments{Line: []string{"--"}, Block: [][2]string{{"{-", "-}"}}, Strings: []types.Quote{doubleQuote}}
+	hclComments        = types.Comments{Line: []string{"#", "//"}, Block: [][2]string{{"/*", "*/"}}, Strings: []types.Quote{doubleQuote}}
+	htmlComments       = types.Comments{Block: [][2]string{{"<!--", "-->"}}}
+	juliaComments      = types.Comments{Line: []string{"#"}, Block: [][2]string{{"#=", "=#"}}, Strings: []types.Quote{doubleQuote}}
+	luaComments        = types.Comments{Line: []string{"--"}, Block: [][2]string{{"--[[", "]]"}}, Strings: quotes}
+	matlabComments     = types.Comments{Line: []string{"%"}, Block: [][2]string{{"%{", "%}"}}, Strings: []types.Quote{doubleQuote}}
+	nixComments        = types.Comments{Line: []string{"#"}, Block: [][2]string{{"/*", "*/"}}, Strings: []types.Quote{doubleQuote}}
+	ocamlComments      = types.Comments{Block: [][2]string{{"(*", "*)"}}, Strings: []types.Quote{doubleQuote}}
+	phpComments        = types.Comments{Line: []string{"//", "#"}, Block: [][2]string{{"/*", "*/"}}, Strings: quotes}
+	powershellComments = types.Comments{Line: []string{"#"}, Block: [][2]string{{"<#", "#>"}}, Strings: quotes}
+	prologComments     = types.Comments{Line: []string{"%"}, Block: [][2]string{{"/*", "*/"}}, Strings: quotes}
+	pythonComments     = types.Comments{Line: []string{"#"}, Block: [][2]string{{`"""`, `"""`}, {", "}}, Strings: quotes}
+	rubyComments       = types.Comments{Line: []string{"#"}, Block: [][2]string{{"=begin", "=end"}}, Strings: quotes}
+	sqlComments        = types.Comments{Line: []string{"--"}, Block: [][2]string{{"/*", "*/"}}, Strings: quotes}
+	vimComments        = types.Comments{Line: []string{`"`}, Strings: []types.Quote{singleQuote}}
+)
+
+// Builtin is the built-in database of programming and markup languages.
+var Builtin = []Definition{
+	{Name: "Assembly", Extensions: []string{".asm", ".s", ".nasm"}, Comments: semicolonComments},
+	{Name: "Batchfile", Extensions: []string{".bat", ".cmd"}, Comments: batchComments},
+	{Name: "C", Extensions: []string{".c", ".h"}, Comments: cComments},
+	{Name: "C#", Extensions: []string{".cs", ".csx"}, Comments: cComments},
+	{Name: "C++", Extensions: []string{".cpp", ".cc", ".cxx", ".c++", ".hpp", ".hh", ".hxx", ".h++", ".h", ".inl", ".ipp"}, Comments: cComments},
+	{Name: "CMake", Extensions: []string{".cmake"}, Filenames: []string{"CMakeLists.txt"}, Comments: hashComments},
+	{Name: "CSS", Extensions: []string{".css"}, Comments: blockComments},
+	{Name: "Clojure", Extensions: []string{".clj", ".cljs", ".cljc", ".edn"}, Comments: semicolonComments},
+	{Name: "CoffeeScript", Extensions: []string{".coffee"}, Comments: coffeeComments},
+	{Name: "Crystal", Extensions: []string{".cr"}, Interpreters: []string{"crystal"}, Comments: hashComments},
+	{Name: "Dart", Extensions: []string{".dart"}, Comments: cComments},
+	{Name: "Dockerfile", Extensions: []string{".dockerfile"}, Filenames: []string{"Dockerfile", "Containerfile"}, Comments: hashComments},
+	{Name: "Elixir", Extensions: []string{".ex", ".exs"}, Interpreters: []string{"elixir"}, Comments: hashComments},
+	{Name: "Elm", Extensions: []string{".elm"}, Comments: haskellComments},
+	{Name: "Erlang", Extensions: []string{".erl", ".hrl"}, Filenames: []string{"rebar.config"}, Interpreters: []string{"escript"}, Comments: percentComments},
+	{Name: "F#", Extensions: []string{".fs", ".fsi", ".fsx"}, Comments: fsharpComments},
+	{Name: "Fortran", Extensions: []string{".f", ".f90", ".f95", ".f03", ".for"}, Comments: fortranComments},
+	{Name: "Go", Extensions: []string{".go"}, Comments: goComments},
+	{Name: "Groovy", Extensions: []string{".groovy", ".gradle"}, Filenames: []string{"Jenkinsfile"}, Interpreters: []string{"groovy"}, Comments: cComments},
+	{Name: "HCL", Extensions: []string{".hcl", ".tf", ".tfvars"}, Comments: hclComments},
+	{Name: "HTML", Extensions: []string{".html", ".htm", ".xhtml"}, Comments: htmlComments},
+	{Name: "Haskell", Extensions: []string{".hs", ".lhs"}, Interpreters: []string{"runhaskell"}, Comments: haskellComments},
+	{Name: "Java", Extensions: []string{".java"}, Comments: cComments},
+	{Name: "JavaScript", Extensions: []string{".js", ".mjs", ".cjs", ".jsx"}, Filenames: []string{"Jakefile"}, Interpreters: []string{"node", "nodejs"}, Comments: jsComments},
+	{Name: "Julia", Extensions: []string{".jl"}, Interpreters: []string{"julia"}, Comments: juliaComments},
+	{Name: "Kotlin", Extensions: []string{".kt", ".kts"}, Comments: cComments},
+	{Name: "Less", Extensions: []string{".less"}, Comments: cComments},
+	{Name: "Lua", Extensions: []string{".lua"}, Interpreters: []string{"lua"}, Comments: luaComments},
+	{Name: "Makefile", Extensions: []string{".mk", ".mak"}, Filenames: []string{"Makefile", "makefile", "GNUmakefile"}, Interpreters: []string{"make"}, Comments: hashComments},
+	{Name: "Nix", Extensions: []string{".nix"}, Comments: nixComments},
+	{Name: "OCaml", Extensions: []string{".ml", ".mli"}, Interpreters: []string{"ocaml"}, Comments: ocamlComments},
+	{Name: "Objective-C", Extensions: []string{".m", ".h"}, Comments: cComments},
+	{Name: "MATLAB", Extensions: []string{".m"}, Comments: matlabComments},
+	{Name: "Objective-C++", Extensions: []string{".mm"}, Comments: cComments},
+	{Name: "PHP", Extensions: []string{".php", ".phtml"}, Interpreters: []string{"php"}, Comments: phpComments},
+	{Name: "Perl", Extensions: []string{".pl", ".pm", ".t"}, Interpreters: []string{"perl"}, Comments: hashComments},
+	{Name: "PowerShell", Extensions: []string{".ps1", ".psm1", ".psd1"}, Interpreters: []string{"pwsh"}, Comments: powershellComments},
+	{Name: "Prolog", Extensions: []string{".pl", ".pro"}, Interpreters: []string{"swipl"}, Comments: prologComments},
+	{Name: "Protocol Buffer", Extensions: []string{".proto"}, Comments: cComments},
+	{Name: "Python", Extensions: []string{".py", ".pyi", ".pyw"}, Filenames: []string{"SConstruct", "SConscript"}, Interpreters: []string{"python"}, Comments: pythonComments},
+	{Name: "R", Extensions: []string{".r"}, Interpreters: []string{"Rscript"}, Comments: hashComments},
+	{Name: "Ruby", Extensions: []string{".rb", ".rake", ".gemspec"}, Filenames: []string{"Gemfile", "Rakefile", "Vagrantfile"}, Interpreters: []string{"ruby"}, Comments: rubyComments},
+	{Name: "Rust", Extensions: []string{".rs"}, Comments: rustComments},
+	{Name: "SCSS", Extensions: []string{".scss", ".sass"}, Comments: cComments},
+	{Name: "SQL", Extensions: []string{".sql"}, Comments: sqlComments},
+	{Name: "Scala", Extensions: []string{".scala", ".sc", ".sbt"}, Interpreters: []string{"scala"}, Comments: cComments},
+	{Name: "Shell", Extensions: []string{".sh", ".bash", ".zsh", ".ksh"}, Filenames: []string{".bashrc", ".bash_profile", ".zshrc", ".profile"}, Interpreters: []string{"sh", "bash", "zsh", "ksh", "dash"}, Comments: hashComments},
+	{Name: "Swift", Extensions: []string{".swift"}, Comments: cComments},
+	{Name: "Tcl", Extensions: []string{".tcl"}, Interpreters: []string{"tclsh", "wish"}, Comments: hashComments},
+	{Name: "TypeScript", Extensions: []string{".ts", ".tsx", ".mts", ".cts"}, Interpreters: []string{"deno", "ts-node"}, Comments: jsComments},
+	{Name: "Vim Script", Extensions: []string{".vim"}, Filenames: []string{".vimrc"}, Comments: vimComments},
+	{Name: "Vue", Extensions: []string{".vue"}, Comments: htmlComments},
+	{Name: "Zig", Extensions: []string{".zig"}, Comments: lineComments},
+}
@@ -0,0 +1,200 @@
+package languages
+
+import (
+	"bytes"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/sirupsen/logrus"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Detector resolves languages of files by the built-in database extended by user languages.
+type Detector struct {
+	langs         []types.Language
+	byExt         map[string][]types.Language
+	byFilename    map[string]types.Language
+	byInterpreter map[string]types.Language
+}
+
+// New creates a detector of the built-in languages overridden or extended by langs.
+// A language named like a built-in one replaces it keeping its filenames, interpreters, comment syntax
+// and extensions, extensions of langs take precedence over the built-in ones. Ext of every language
+// returns the extensions resolved to it.
+func New(langs ...types.Language) *Detector {
+	d := &Detector{
+		byExt:         make(map[string][]types.Language),
+		byFilename:    make(map[string]types.Language),
+		byInterpreter: make(map[string]types.Language),
+	}
+
+	custom := make(map[string]types.Language, len(langs))
+	claimed := make(map[string]string)
+
+	for _, lang := range langs {
+		custom[strings.ToLower(lang.Name())] = lang
+
+		for _, ext := range lang.Ext() {
+			claimed[strings.ToLower(ext)] = strings.ToLower(lang.Name())
+		}
+	}
+
+	for _, def := range Builtin {
+		key, name := strings.ToLower(def.Name), def.Name
+
+		var ext []string
+
+		if lang, ok := custom[key]; ok {
+			name, ext = lang.Name(), ownExtensions(key, lang.Ext(), claimed)
+		}
+
+		builtin := ownExtensions(key, def.Extensions, claimed)
+
+		for _, e := range builtin {
+			if !slices.Contains(ext, e) {
+				ext = append(ext, e)
+			}
+		}
+
+		lang := models.NewLanguageWithComments(name, ext, def.Comments)
+
+		custom[key] = lang
+		d.langs = append(d.langs, lang)
+
+		for _, ext := range builtin {
+			d.byExt[ext] = append(d.byExt[ext], lang)
+		}
+
+		for _, filename := range def.Filenames {
+			d.byFilename[filename] = lang
+		}
+
+		for _, interpreter := range def.Interpreters {
+			d.byInterpreter[interpreter] = lang
+		}
+	}
+
+	for _, lang := range langs {
+		if custom[strings.ToLower(lang.Name())] == lang {
+			d.langs = append(d.langs, lang)
+		}
+
+		for _, ext := range lang.Ext() {
+			d.byExt[strings.ToLower(ext)] = []types.Language{custom[strings.ToLower(lang.Name())]}
+		}
+	}
+
+	return d
+}
+
+// ownExtensions returns extensions of the language named key which aren't claimed by another user language.
+func ownExtensions(key string, extensions []string, claimed map[string]string) (res []string) {
+	for _, ext := range extensions {
+		ext = strings.ToLower(ext)
+
+		if owner, ok := claimed[ext]; (!ok || owner == key) && !slices.Contains(res, ext) {
+			res = append(res, ext)
+		}
+	}
+
+	return
+}
+
+// Languages returns all languages known to the detector.
+func (d *Detector) Languages() []types.Language {
+	return d.langs
+}
+
+// Language returns the language named name.
+func (d *Detector) Language(name string) (types.Language, bool) {
+	for _, lang := range d.langs {
+		if strings.EqualFold(lang.Name(), name) {
+			return lang, true
+		}
+	}
+
+	return nil, false
+}
+
+// Resolver returns the language resolver choosing the language with the largest share in hint
+// for ambiguous extensions, hint maps language names to their share in the repository and can be nil.
+// The content is only read for files without extension to detect the shebang interpreter.
+func (d *Detector) Resolver(hint map[string]float32) types.LanguageResolver {
+	shares := make(map[string]float32, len(hint))
+
+	for name, share := range hint {
+		shares[strings.ToLower(name)] = share
+	}
+
+	return func(file string, content func() ([]byte, error)) (types.Language, bool) {
+		name := path.Base(file)
+
+		if lang, ok := d.byFilename[name]; ok {
+			return lang, true
+		}
+
+		ext := strings.ToLower(path.Ext(name))
+
+		if candidates := d.byExt[ext]; len(candidates) != 0 {
+			return choose(candidates, shares), true
+		}
+
+		if ext == "" && content != nil {
+			data, err := content()
+			if err != nil {
+				logrus.Debugf("error reading file %s: %v", file, err)
+			} else if lang, ok := d.interpreter(data); ok {
+				return lang, true
+			}
+		}
+
+		logrus.Debugf("skipping file %s of unknown language", file)
+
+		return nil, false
+	}
+}
+
+// interpreter resolves the language by the interpreter in the shebang line of data.
+func (d *Detector) interpreter(data []byte) (types.Language, bool) {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+
+	shebang, ok := bytes.CutPrefix(line, []byte("#!"))
+	if !ok {
+		return nil, false
+	}
+
+	fields := strings.Fields(string(shebang))
+	if len(fields) == 0 {
+		return nil, false
+	}
+
+	program := path.Base(fields[0])
+
+	if program == "env" {
+		program = ""
+
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				program = path.Base(field)
+				break
+			}
+		}
+	}
+
+	lang, ok := d.byInterpreter[strings.TrimRight(program, "0123456789.")]
+
+	return lang, ok
+}
+
+func choose(candidates []types.Language, shares map[string]float32) types.Language {
+	lang, best := candidates[0], float32(0)
+
+	for _, candidate := range candidates {
+		if share := shares[strings.ToLower(candidate.Name())]; share > best {
+			lang, best = candidate, share
+		}
+	}
+
+	return lang
+}
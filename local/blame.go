@@ -0,0 +1,149 @@
+package local
+
+import (
+	"context"
+	"github.com/gaarutyunov/gitstat/languages"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+	"io"
+	"sync"
+	"time"
+)
+
+// Blame calls f with the blame of every text file in the tree of commit which language is resolved by lang.
+// Files classified as generated or vendored are not blamed, excluded is called with their lines instead.
+// Files that fail to blame are skipped calling failed.
+func Blame(
+	ctx context.Context,
+	commit *object.Commit,
+	lang types.LanguageResolver,
+	classifier *languages.Classifier,
+	f func(path string, lang types.Language, blame *git.BlameResult),
+	excluded func(path string, lang types.Language, kind types.FileKind, lines []string),
+	failed func(path string, err error),
+) error {
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	return tree.Files().ForEach(func(file *object.File) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		l, ok := lang(file.Name, func() ([]byte, error) {
+			r, err := file.Reader()
+			if err != nil {
+				return nil, err
+			}
+			defer r.Close()
+
+			return io.ReadAll(io.LimitReader(r, 512))
+		})
+		if !ok {
+			return nil
+		}
+
+		if binary, err := file.IsBinary(); err != nil || binary {
+			return nil
+		}
+
+		lines, err := file.Lines()
+		if err != nil {
+			logrus.Debugf("error reading file %s at commit %s: %v", file.Name, commit.Hash, err)
+			failed(file.Name, err)
+			return nil
+		}
+
+		kind := classifier.Path(file.Name)
+		if kind == types.Source {
+			kind = classifier.Content(file.Name, lines)
+		}
+
+		if kind != types.Source {
+			excluded(file.Name, l, kind, lines)
+			return nil
+		}
+
+		blame, err := git.Blame(commit, file.Name)
+		if err != nil {
+			logrus.Debugf("error gettings blame for file %s at commit %s: %v", file.Name, commit.Hash, err)
+			failed(file.Name, err)
+			return nil
+		}
+
+		f(file.Name, l, blame)
+
+		return nil
+	})
+}
+
+// Committers returns a function returning the committer of a commit of r by its hash,
+// since go-git blame only reports authors. Commits are looked up once, unknown commits have an empty committer.
+func Committers(r *git.Repository) func(hash plumbing.Hash) object.Signature {
+	var committers sync.Map
+
+	return func(hash plumbing.Hash) object.Signature {
+		if signature, ok := committers.Load(hash); ok {
+			return signature.(object.Signature)
+		}
+
+		c, err := r.CommitObject(hash)
+		if err != nil {
+			return object.Signature{}
+		}
+
+		committers.Store(hash, c.Committer)
+
+		return c.Committer
+	}
+}
+
+// BlameRanges groups consecutive lines of blame last changed by the same commit,
+// kinds are the kinds of the lines and committer resolves committers of commits.
+func BlameRanges(blame *git.BlameResult, kinds []types.LineKind, committer func(hash plumbing.Hash) object.Signature) []types.BlameRange {
+	var ranges []types.BlameRange
+
+	for i, line := range blame.Lines {
+		if i == 0 || line.Hash.String() != ranges[len(ranges)-1].Commit {
+			signature := committer(line.Hash)
+
+			ranges = append(ranges, types.BlameRange{
+				Commit:    line.Hash.String(),
+				Author:    types.Person{Name: line.AuthorName, Email: line.Author},
+				Committer: types.Person{Name: signature.Name, Email: signature.Email},
+				Date:      signature.When,
+				Lines:     make(map[types.LineKind]int64, 3),
+			})
+		}
+
+		ranges[len(ranges)-1].Lines[kinds[i]]++
+	}
+
+	return ranges
+}
+
+// CommitAt returns the last commit before t following the first parents of commit
+// or nil if there is no such commit.
+func CommitAt(commit *object.Commit, t time.Time) (*object.Commit, error) {
+	for commit != nil && !commit.Committer.When.Before(t) {
+		if commit.NumParents() == 0 {
+			return nil, nil
+		}
+
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+
+		commit = parent
+	}
+
+	return commit, nil
+}
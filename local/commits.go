@@ -0,0 +1,60 @@
+package local
+
+import (
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+)
+
+// processCommits counts changes of non-merge commits in the history of head.
+func (s *Stats) processCommits(repo *Repository, head *object.Commit, boundary Boundary, language types.LanguageResolver) error {
+	iter := object.NewCommitPreorderIter(head, nil, nil)
+	defer iter.Close()
+
+	return iter.ForEach(func(commit *object.Commit) error {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		default:
+		}
+
+		// changes of boundary commits of a shallow clone can't be told apart from older lines
+		if commit.NumParents() > 1 || boundary.Contains(commit.Hash) || !s.period.Contains(commit.Committer.When) {
+			return nil
+		}
+
+		stats, err := commit.Stats()
+		if err != nil {
+			logrus.Debugf("error getting stats of commit %s in repository %s: %v", commit.Hash, repo.Path, err)
+			return nil
+		}
+
+		total, changes := CommitChanges(stats, language)
+
+		s.commits.Add(repo.Path, s.getUser(commit.Author.Email), total, changes)
+
+		return nil
+	})
+}
+
+// CommitChanges sums additions and deletions of all files in stats and of files per language resolved by lang.
+func CommitChanges(stats object.FileStats, lang types.LanguageResolver) (total types.CommitCount, changes map[types.Language]types.CommitCount) {
+	changes = make(map[types.Language]types.CommitCount)
+
+	for _, file := range stats {
+		total.Additions += file.Addition
+		total.Deletions += file.Deletion
+
+		l, ok := lang(file.Name, nil)
+		if !ok {
+			continue
+		}
+
+		n := changes[l]
+		n.Additions += file.Addition
+		n.Deletions += file.Deletion
+		changes[l] = n
+	}
+
+	return total, changes
+}
@@ -0,0 +1,35 @@
+package local
+
+import (
+	"errors"
+	"github.com/gaarutyunov/gitstat/filter"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ReadConfig reads the repository configuration at commit, nil is returned if there is none.
+func ReadConfig(commit *object.Commit) (*filter.Config, error) {
+	data, err := ReadFile(commit, filter.ConfigFile)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	return filter.ParseConfig(data)
+}
+
+// ReadFile reads the file at path in the tree of commit, nil is returned if there is no such file.
+func ReadFile(commit *object.Commit, path string) ([]byte, error) {
+	file, err := commit.File(path)
+	if err != nil {
+		if errors.Is(err, object.ErrFileNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	data, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(data), nil
+}
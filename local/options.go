@@ -0,0 +1,153 @@
+package local
+
+import (
+	"context"
+	"github.com/gaarutyunov/gitstat/filter"
+	"github.com/gaarutyunov/gitstat/languages"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/gaarutyunov/gitstat/utils"
+	"github.com/schollz/progressbar/v3"
+	"time"
+)
+
+// WithLanguages overrides or extends the built-in languages detected in repositories.
+func WithLanguages(langs ...types.Language) Option {
+	return func(g *Stats) {
+		g.detector = languages.New(langs...)
+	}
+}
+
+func WithUsers(users ...types.User) Option {
+	return func(g *Stats) {
+		for _, user := range users {
+			g.addUser(user)
+		}
+	}
+}
+
+// WithRef sets the revision to compute statistics at, HEAD is used by default.
+func WithRef(ref string) Option {
+	return func(g *Stats) {
+		if ref != "" {
+			g.ref = ref
+		}
+	}
+}
+
+func WithContext(ctx context.Context) Option {
+	return func(g *Stats) {
+		g.ctx = ctx
+	}
+}
+
+func WithProgress(progress bool, option ...progressbar.Option) Option {
+	return func(g *Stats) {
+		if !progress {
+			g.progress = nil
+		} else {
+			g.progress = func(n int64) *progressbar.ProgressBar {
+				if len(option) == 0 {
+					return progressbar.Default(n)
+				}
+				return progressbar.NewOptions64(n, option...)
+			}
+		}
+	}
+}
+
+// WithFilter sets the filter applied to projects before they are processed.
+func WithFilter(f *filter.Projects) Option {
+	return func(g *Stats) {
+		if f != nil {
+			g.filter = f
+		}
+	}
+}
+
+// WithPaths sets the filter of files by their paths inside repositories.
+func WithPaths(f *filter.Paths) Option {
+	return func(g *Stats) {
+		if f != nil {
+			g.pathFilter = f
+		}
+	}
+}
+
+// WithIncluded counts lines of generated or vendored files like the ones of regular files.
+func WithIncluded(kinds ...types.FileKind) Option {
+	return func(g *Stats) {
+		g.include = kinds
+	}
+}
+
+// WithCommits enables counting of commits in the history of the ref.
+func WithCommits(commits bool) Option {
+	return func(g *Stats) {
+		if commits {
+			g.commits = models.NewCommitCounter()
+		} else {
+			g.commits = nil
+		}
+	}
+}
+
+// WithSince restricts statistics to lines and commits since t.
+func WithSince(t time.Time) Option {
+	return func(g *Stats) {
+		g.period.Since = t
+	}
+}
+
+// WithUntil restricts statistics to lines and commits before t.
+func WithUntil(t time.Time) Option {
+	return func(g *Stats) {
+		g.period.Until = t
+	}
+}
+
+// WithAt computes line statistics at the last commit before t instead of the ref.
+func WithAt(t time.Time) Option {
+	return func(g *Stats) {
+		g.at = t
+	}
+}
+
+// WithBaseline restores repositories which head didn't move since the baseline report instead of blaming them.
+func WithBaseline(b *models.Baseline) Option {
+	return func(g *Stats) {
+		g.baseline = b
+	}
+}
+
+// WithCheckpoint writes every processed project to the checkpoint.
+func WithCheckpoint(c *models.Checkpoint) Option {
+	return func(g *Stats) {
+		g.checkpoint = c
+	}
+}
+
+// WithConcurrency bounds the number of repositories blamed at once, 0 means no bound.
+func WithConcurrency(projects int) Option {
+	return func(g *Stats) {
+		g.projectPool = utils.NewPool(projects)
+	}
+}
+
+// WithOwners counts lines per file of every repository to report their owners.
+func WithOwners(enabled bool) Option {
+	return func(g *Stats) {
+		if enabled {
+			g.owners = models.NewOwnerCounters()
+		}
+	}
+}
+
+// WithFacts keeps the blame ranges of every file.
+func WithFacts(enabled bool) Option {
+	return func(g *Stats) {
+		if enabled {
+			g.facts = models.NewFacts()
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package local
+
+import (
+	"errors"
+	"github.com/go-git/go-git/v5"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Repository struct {
+	*git.Repository
+	// Path is the repository path relative to the directory it was found in.
+	Path string
+	Dir  string
+}
+
+// isRepository reports whether dir is a working tree with a .git entry or a bare repository.
+func isRepository(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, git.GitDirName)); err == nil {
+		return true
+	}
+
+	if !strings.HasSuffix(dir, ".git") {
+		return false
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "HEAD"))
+
+	return err == nil
+}
+
+// findRepositories returns the repository at root or all repositories nested in root.
+func findRepositories(root string) (repos []*Repository, err error) {
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		if !isRepository(path) {
+			return nil
+		}
+
+		r, err := git.PlainOpen(path)
+		if err != nil {
+			if errors.Is(err, git.ErrRepositoryNotExists) {
+				return nil
+			}
+			return err
+		}
+
+		rel, err := filepath.Rel(filepath.Dir(root), path)
+		if err != nil {
+			return err
+		}
+
+		repos = append(repos, &Repository{
+			Repository: r,
+			Path:       filepath.ToSlash(rel),
+			Dir:        path,
+		})
+
+		return filepath.SkipDir
+	})
+
+	return
+}
@@ -0,0 +1,86 @@
+package local
+
+import (
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+type (
+	// Boundary is the set of commits at the boundary of a shallow clone, it's empty for full clones.
+	// Lines blamed to a boundary commit may be last changed by any older commit missing from the clone.
+	Boundary map[plumbing.Hash]struct{}
+
+	// shallowStorer presents boundary commits of a shallow clone as root commits.
+	shallowStorer struct {
+		storer.EncodedObjectStorer
+		boundary Boundary
+	}
+
+	// rootCommit is a boundary commit without parents keeping the hash of the original commit.
+	rootCommit struct {
+		*plumbing.MemoryObject
+		hash plumbing.Hash
+	}
+)
+
+// Shallow returns the commit at hash of r with the boundary of the clone, boundary commits are root commits
+// of the history of the returned commit, since go-git fails to blame files changed before them otherwise.
+func Shallow(r *git.Repository, hash plumbing.Hash) (*object.Commit, Boundary, error) {
+	hashes, err := r.Storer.Shallow()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	boundary := make(Boundary, len(hashes))
+
+	for _, h := range hashes {
+		boundary[h] = struct{}{}
+	}
+
+	if len(boundary) == 0 {
+		commit, err := r.CommitObject(hash)
+		return commit, boundary, err
+	}
+
+	commit, err := object.GetCommit(&shallowStorer{EncodedObjectStorer: r.Storer, boundary: boundary}, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return commit, boundary, nil
+}
+
+// Contains reports whether the commit is at the boundary of the clone.
+func (b Boundary) Contains(hash plumbing.Hash) bool {
+	_, ok := b[hash]
+	return ok
+}
+
+func (s *shallowStorer) EncodedObject(t plumbing.ObjectType, hash plumbing.Hash) (plumbing.EncodedObject, error) {
+	obj, err := s.EncodedObjectStorer.EncodedObject(t, hash)
+	if err != nil || obj.Type() != plumbing.CommitObject || !s.boundary.Contains(hash) {
+		return obj, err
+	}
+
+	var commit object.Commit
+
+	if err := commit.Decode(obj); err != nil {
+		return nil, err
+	}
+
+	commit.ParentHashes = nil
+
+	root := &rootCommit{MemoryObject: &plumbing.MemoryObject{}, hash: hash}
+
+	if err := commit.Encode(root); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+func (c *rootCommit) Hash() plumbing.Hash {
+	return c.hash
+}
@@ -0,0 +1,509 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/filter"
+	"github.com/gaarutyunov/gitstat/languages"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/owners"
+	"github.com/gaarutyunov/gitstat/types"
+	"github.com/gaarutyunov/gitstat/utils"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/schollz/progressbar/v3"
+	"github.com/sirupsen/logrus"
+	"slices"
+	"sync"
+	"time"
+)
+
+type (
+	Stats struct {
+		ctx         context.Context
+		paths       []string
+		ref         string
+		so          sync.Once
+		detector    *languages.Detector
+		mx          sync.RWMutex
+		userByAlias map[string]types.User
+		counter     map[types.User]types.PerLanguageCounter
+		se          sync.Once
+		err         error
+		progress    func(n int64) *progressbar.ProgressBar
+		filter      *filter.Projects
+		pathFilter  *filter.Paths
+		include     []types.FileKind
+		excluded    models.MapKindCounter
+		projects    *models.ProjectCounters
+		baseline    *models.Baseline
+		checkpoint  *models.Checkpoint
+		projectPool *utils.Pool
+		failures    models.Failures
+		owners      *models.OwnerCounters
+		facts       *models.Facts
+		commits     *models.CommitCounter
+		period      utils.Period
+		at          time.Time
+	}
+)
+
+func (s *Stats) Err() error {
+	return s.err
+}
+
+type Option func(*Stats)
+
+// New creates statistics for repositories found in paths.
+// Every path is either a repository or a directory containing repositories.
+func New(paths []string, opts ...Option) *Stats {
+	g := &Stats{
+		ctx:         context.Background(),
+		paths:       paths,
+		ref:         string(plumbing.HEAD),
+		userByAlias: make(map[string]types.User),
+		counter:     make(map[types.User]types.PerLanguageCounter),
+		detector:    languages.New(),
+		filter:      &filter.Projects{},
+		pathFilter:  &filter.Paths{},
+		projects:    models.NewProjectCounters(),
+		progress: func(n int64) *progressbar.ProgressBar {
+			return progressbar.Default(n)
+		},
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+func (s *Stats) count() {
+	for user := range s.counter {
+		s.counter[user] = models.MakeMapLanguageCounter(s.detector.Languages())
+	}
+
+	s.counter[models.DefaultUser] = models.MakeMapLanguageCounter(s.detector.Languages())
+	s.excluded = models.MakeMapKindCounter(s.detector.Languages())
+
+	repos, err := s.getRepos()
+	if err != nil {
+		s.se.Do(func() {
+			s.err = err
+		})
+		return
+	}
+
+	repos = slices.DeleteFunc(repos, func(repo *Repository) bool {
+		reason := s.filter.Skip(filter.Project{Path: repo.Path})
+		if reason != "" {
+			logrus.Debugf("repository %s is skipped: %s", repo.Path, reason)
+		}
+
+		return reason != ""
+	})
+
+	var bar *progressbar.ProgressBar
+
+	if s.progress != nil {
+		bar = s.progress(int64(len(repos)))
+	}
+
+	projects := s.projectPool.Group()
+
+	for _, repo := range repos {
+		err := projects.Go(s.ctx, func() {
+			err := s.processRepo(repo)
+			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					s.se.Do(func() {
+						s.err = err
+					})
+					return
+				}
+				logrus.Error(err)
+				s.failures.Add(models.NewFailure(repo.Path, "", 0, err))
+			} else {
+				s.complete(repo.Path)
+			}
+
+			if bar != nil {
+				_ = bar.Add(1)
+			}
+		})
+		if err != nil {
+			break
+		}
+	}
+
+	projects.Wait()
+
+	if err := s.ctx.Err(); err != nil {
+		s.se.Do(func() {
+			s.err = err
+		})
+	}
+}
+
+func (s *Stats) getRepos() (repos []*Repository, err error) {
+	for _, path := range s.paths {
+		found, err := findRepositories(path)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("error finding repositories in %s", path), err)
+		}
+
+		repos = append(repos, found...)
+	}
+
+	return repos, nil
+}
+
+// addUser makes user resolvable by its email and aliases, the counter is created in count.
+func (s *Stats) addUser(user types.User) {
+	s.counter[user] = nil
+
+	for _, alias := range user.GetAliases() {
+		s.userByAlias[alias] = user
+	}
+
+	s.userByAlias[user.GetEmail()] = user
+}
+
+// getUser resolves the user by email registering unknown authors on the fly,
+// since there is no server to list users from.
+func (s *Stats) getUser(email string) types.User {
+	s.mx.RLock()
+	user, ok := s.userByAlias[email]
+	s.mx.RUnlock()
+
+	if ok {
+		return user
+	}
+
+	if email == "" {
+		return models.DefaultUser
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if user, ok := s.userByAlias[email]; ok {
+		return user
+	}
+
+	user = models.NewUser(email, nil)
+	s.counter[user] = models.MakeMapLanguageCounter(s.detector.Languages())
+	s.userByAlias[email] = user
+
+	return user
+}
+
+func (s *Stats) getCounter(user types.User) models.MapLanguageCounter {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	return s.counter[user].(models.MapLanguageCounter)
+}
+
+func (s *Stats) processRepo(repo *Repository) error {
+	hash, err := repo.ResolveRevision(plumbing.Revision(s.ref))
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			logrus.Debugf("reference %s not found in repo %s", s.ref, repo.Path)
+			return nil
+		}
+		return errors.Join(fmt.Errorf("error resolving %s in repo %s", s.ref, repo.Path), err)
+	}
+
+	// CI checkouts are usually shallow, history before the boundary of the clone is missing
+	commit, boundary, err := Shallow(repo.Repository, *hash)
+	if err != nil {
+		return errors.Join(fmt.Errorf("error getting commit %s in repo %s", hash, repo.Path), err)
+	}
+
+	if !s.at.IsZero() {
+		commit, err = CommitAt(commit, s.at)
+		if err != nil {
+			return errors.Join(fmt.Errorf("error getting commit before %s in repo %s", s.at, repo.Path), err)
+		}
+
+		if commit == nil {
+			if len(boundary) != 0 {
+				logrus.Warnf("no commits before %s in the shallow clone of repo %s", s.at, repo.Path)
+			} else {
+				logrus.Debugf("no commits before %s in repo %s", s.at, repo.Path)
+			}
+			return nil
+		}
+	}
+
+	project := s.projects.Get(repo.Path)
+	project.SetHead(commit.Hash.String())
+	s.facts.SetHead(repo.Path, commit.Hash.String())
+
+	if baseline, ok := s.baseline.Project(repo.Path, commit.Hash.String()); ok {
+		logrus.Debugf("head of repo %s didn't move, using baseline", repo.Path)
+		s.restore(project, baseline)
+		return nil
+	}
+
+	config, err := ReadConfig(commit)
+	if err != nil {
+		logrus.Warnf("ignoring %s of repo %s: %v", filter.ConfigFile, repo.Path, err)
+	}
+
+	attributes, err := ReadFile(commit, languages.AttributesFile)
+	if err != nil {
+		logrus.Warnf("ignoring %s of repo %s: %v", languages.AttributesFile, repo.Path, err)
+	}
+
+	language := s.pathFilter.With(config).Language(s.detector.Resolver(nil))
+	classifier := languages.NewClassifier(attributes, s.include...)
+
+	if s.owners != nil {
+		for _, path := range owners.Locations {
+			if data, err := ReadFile(commit, path); err == nil && data != nil {
+				s.owners.Get(repo.Path).SetCodeowners(path, data)
+				break
+			}
+		}
+	}
+
+	fileOwners := s.owners.Get(repo.Path)
+
+	// go-git blame only reports authors, lines are credited to committers as in the other backends
+	committer := Committers(repo.Repository)
+
+	err = Blame(s.ctx, commit, language, classifier, func(path string, lang types.Language, blame *git.BlameResult) {
+		scanner := models.NewLineScanner(lang)
+		kinds := make([]types.LineKind, 0, len(blame.Lines))
+
+		for _, line := range blame.Lines {
+			kind := scanner.Scan(line.Text)
+			kinds = append(kinds, kind)
+
+			// lines of the boundary of a shallow clone may be changed by older commits,
+			// so they are credited to nobody and their date is unknown
+			if boundary.Contains(line.Hash) {
+				if s.period.IsZero() {
+					s.add(project, models.DefaultUser, lang, kind, 1)
+				}
+				continue
+			}
+
+			signature := committer(line.Hash)
+
+			if !s.period.Contains(signature.When) {
+				continue
+			}
+
+			user := s.getUser(signature.Email)
+
+			s.add(project, user, lang, kind, 1)
+
+			if kind == types.Code {
+				fileOwners.Add(path, user, 1)
+			}
+		}
+
+		if s.facts != nil {
+			ranges := BlameRanges(blame, kinds, committer)
+
+			for i, r := range ranges {
+				if boundary.Contains(plumbing.NewHash(r.Commit)) {
+					ranges[i] = types.BlameRange{Commit: r.Commit, Lines: r.Lines}
+				}
+			}
+
+			s.facts.Add(repo.Path, types.FileFacts{
+				Path:     path,
+				Language: lang,
+				Kind:     types.Source,
+				Ranges:   ranges,
+			})
+		}
+	}, func(path string, lang types.Language, kind types.FileKind, lines []string) {
+		counted := models.CountLines(lang, lines)
+
+		s.addExcluded(project, kind, lang, counted)
+		s.facts.Add(repo.Path, types.FileFacts{Path: path, Language: lang, Kind: kind, Lines: counted})
+	}, func(path string, err error) {
+		s.failures.Add(models.NewFailure(repo.Path, path, 0, err))
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.commits != nil {
+		return s.processCommits(repo, commit, boundary, classifier.Language(language))
+	}
+
+	return nil
+}
+
+// add counts n lines of user in lang for totals and project.
+func (s *Stats) add(project *models.ProjectCounter, user types.User, lang types.Language, kind types.LineKind, n int64) {
+	s.getCounter(user)[lang].Add(kind, n)
+	project.Add(user, lang, kind, n)
+}
+
+// addExcluded counts lines of a generated or vendored file of project.
+func (s *Stats) addExcluded(project *models.ProjectCounter, kind types.FileKind, lang types.Language, lines map[types.LineKind]int64) {
+	s.excluded.Add(kind, lang, lines)
+	project.AddExcluded(kind, lang, lines)
+}
+
+// restore counts lines of project from its baseline instead of blaming it.
+func (s *Stats) restore(project *models.ProjectCounter, baseline *models.BaselineProject) {
+	baseline.Restore(
+		s.detector.Language,
+		func(email string, lang types.Language, kind types.LineKind, n int64) {
+			user := models.DefaultUser
+			if email != user.GetEmail() {
+				user = s.getUser(email)
+			}
+
+			s.add(project, user, lang, kind, n)
+		},
+		func(kind types.FileKind, lang types.Language, lines map[types.LineKind]int64) {
+			s.addExcluded(project, kind, lang, lines)
+		},
+	)
+}
+
+// complete writes lines of project to the checkpoint once it is processed,
+// projects with failed files are incomplete and processed again on resume.
+func (s *Stats) complete(project string) {
+	counter, ok := s.projects.Find(project)
+	if !ok || s.failures.Has(project) {
+		return
+	}
+
+	if err := s.checkpoint.Add(project, counter); err != nil {
+		logrus.Warnf("error writing checkpoint for %s: %v", project, err)
+	}
+}
+
+func (s *Stats) PerProject() map[string]types.ProjectCounter {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.projects.PerProject()
+}
+
+func (s *Stats) Owners() map[string]types.FileOwnerCounter {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.owners.PerProject()
+}
+
+func (s *Stats) Facts() []types.ProjectFacts {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.facts.List()
+}
+
+func (s *Stats) Failures() []types.Failure {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.failures.List()
+}
+
+func (s *Stats) Excluded() map[types.FileKind]types.PerLanguageCounter {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return s.excluded.NonEmpty()
+}
+
+func (s *Stats) Commits() types.CommitCounter {
+	s.so.Do(s.count)
+
+	if s.err != nil || s.commits == nil {
+		return nil
+	}
+
+	return s.commits
+}
+
+func (s *Stats) PerUser() (res map[types.User]types.PerLanguageCounter) {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return
+	}
+
+	res = make(map[types.User]types.PerLanguageCounter)
+
+	for user, counter := range s.counter {
+		if counter.Total() == 0 {
+			continue
+		}
+
+		res[user] = counter
+	}
+
+	return
+}
+
+func (s *Stats) PerLanguage() (res map[types.Language]int) {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return
+	}
+
+	res = make(map[types.Language]int)
+
+	for _, langs := range s.counter {
+		for lang, n := range langs.PerLanguage() {
+			res[lang] += n
+		}
+	}
+
+	return
+}
+
+func (s *Stats) PerKind() map[types.LineKind]map[types.Language]int {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return nil
+	}
+
+	return models.MapUserCounter(s.counter).PerKind()
+}
+
+func (s *Stats) Total() (total int) {
+	s.so.Do(s.count)
+
+	if s.err != nil {
+		return
+	}
+
+	for _, langs := range s.counter {
+		total += langs.Total()
+	}
+
+	return total
+}
@@ -0,0 +1,189 @@
+package local
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newRepo returns a repository with main.go committed with every content by the author with the same index,
+// along with hashes of the commits.
+func newRepo(t *testing.T, authors, contents []string) (string, *git.Repository, []plumbing.Hash) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wt, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	when := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	var hashes []plumbing.Hash
+
+	for i, email := range authors {
+		if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(contents[i]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := wt.Add("main.go"); err != nil {
+			t.Fatal(err)
+		}
+
+		signature := &object.Signature{Name: email, Email: email, When: when.AddDate(0, 0, i)}
+
+		hash, err := wt.Commit(email, &git.CommitOptions{Author: signature, Committer: signature})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hashes = append(hashes, hash)
+	}
+
+	return dir, r, hashes
+}
+
+// newShallowRepo returns a repository with main.go changed by three commits of a@example.com,
+// b@example.com and c@example.com, cut as a shallow clone of depth 2: the first commit is missing
+// and the second is at the boundary.
+func newShallowRepo(t *testing.T) string {
+	t.Helper()
+
+	content := "package main\n\nfunc a() {}\n"
+
+	dir, r, hashes := newRepo(t,
+		[]string{"a@example.com", "b@example.com", "c@example.com"},
+		[]string{content, content + "func b() {}\n", content + "func b() {}\nfunc c() {}\n"},
+	)
+
+	if err := r.Storer.SetShallow(hashes[1:2]); err != nil {
+		t.Fatal(err)
+	}
+
+	first := hashes[0].String()
+
+	if err := os.Remove(filepath.Join(dir, git.GitDirName, "objects", first[:2], first[2:])); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+// linesPerUser returns code lines per user email.
+func linesPerUser(s *Stats) map[string]int {
+	res := make(map[string]int)
+
+	for user, counter := range s.PerUser() {
+		if n := counter.Total(); n != 0 {
+			res[user.GetEmail()] += n
+		}
+	}
+
+	return res
+}
+
+func TestStatsShallowClone(t *testing.T) {
+	s := New([]string{newShallowRepo(t)}, WithProgress(false), WithCommits(true))
+
+	got := linesPerUser(s)
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{
+		"c@example.com":               1,
+		models.DefaultUser.GetEmail(): 3,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got lines %v, want %v", got, want)
+	}
+
+	for email, n := range want {
+		if got[email] != n {
+			t.Errorf("got %d lines of %s, want %d", got[email], email, n)
+		}
+	}
+
+	if failures := s.Failures(); len(failures) != 0 {
+		t.Errorf("unexpected failures %v", failures)
+	}
+
+	commits := s.Commits().PerUser()
+
+	if len(commits) != 1 {
+		t.Fatalf("got commits of %d users, want only c@example.com", len(commits))
+	}
+
+	for user, counter := range commits {
+		if user.GetEmail() != "c@example.com" || counter.Total().Commits != 1 || counter.Total().Additions != 1 {
+			t.Errorf("got %v of %s, want 1 commit adding 1 line of c@example.com", counter.Total(), user.GetEmail())
+		}
+	}
+}
+
+func TestStatsShallowCloneSince(t *testing.T) {
+	s := New([]string{newShallowRepo(t)}, WithProgress(false), WithSince(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)))
+
+	got := linesPerUser(s)
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got["c@example.com"] != 1 || len(got) != 1 {
+		t.Errorf("got lines %v, want only 1 line of c@example.com since lines of the boundary have no date", got)
+	}
+}
+
+func TestStatsBaselineRoundTrip(t *testing.T) {
+	content := "package main\n\nfunc a() {}\n"
+
+	// b@example.com only adds a blank and a comment line
+	dir, _, _ := newRepo(t,
+		[]string{"a@example.com", "b@example.com"},
+		[]string{content, content + "\n// b\n"},
+	)
+
+	report := func(s *Stats) []byte {
+		t.Helper()
+
+		data, err := json.Marshal(models.NewStats(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		return data
+	}
+
+	want := report(New([]string{dir}, WithProgress(false)))
+
+	baseline, err := models.LoadBaseline(bytes.NewReader(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := baseline.Projects[filepath.Base(dir)].PerUserLines["b@example.com"]; !ok {
+		t.Fatalf("lines of b@example.com without code lines are missing in the report %s", want)
+	}
+
+	if got := report(New([]string{dir}, WithProgress(false), WithBaseline(baseline))); !bytes.Equal(got, want) {
+		t.Errorf("got restored report %s, want %s", got, want)
+	}
+}
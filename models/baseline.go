@@ -0,0 +1,142 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/gaarutyunov/gitstat/types"
+	"io"
+	"slices"
+)
+
+type (
+	// Baseline is a previous JSON report, projects which head didn't move since
+	// are restored from it instead of being processed again unless they failed.
+	Baseline struct {
+		Projects map[string]*BaselineProject `json:"projects"`
+		Failures []types.Failure             `json:"failures"`
+	}
+
+	// BaselineProject is the part of ProjectStats needed to restore its counters.
+	BaselineProject struct {
+		Head         string                                         `json:"head"`
+		PerUserLines map[string]map[types.LineKind]map[string]int64 `json:"per_user_lines"`
+		Excluded     map[types.FileKind]ExcludedLines               `json:"excluded"`
+	}
+
+	// ExcludedLines are code lines of excluded files per language name and lines of every kind,
+	// which reports written before kinds were counted don't have.
+	ExcludedLines struct {
+		PerLang map[string]int64                    `json:"per_lang"`
+		Lines   map[types.LineKind]map[string]int64 `json:"lines,omitempty"`
+	}
+)
+
+// LoadBaseline decodes a report written with the json format.
+func LoadBaseline(r io.Reader) (*Baseline, error) {
+	var b Baseline
+
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// Project returns the baseline of project if its head is still head,
+// projects with failures are incomplete and never restored.
+func (b *Baseline) Project(project, head string) (*BaselineProject, bool) {
+	if b == nil || head == "" {
+		return nil, false
+	}
+
+	p, ok := b.Projects[project]
+	if !ok || p.Head != head || b.failed(project) {
+		return nil, false
+	}
+
+	return p, true
+}
+
+// failed reports whether project or any of its files failed.
+func (b *Baseline) failed(project string) bool {
+	return slices.ContainsFunc(b.Failures, func(failure types.Failure) bool {
+		return failure.Project == project
+	})
+}
+
+// Restore calls add with lines of every user and excluded with lines of excluded files,
+// languages are resolved by name with lang and skipped if unknown.
+func (p *BaselineProject) Restore(
+	lang func(name string) (types.Language, bool),
+	add func(email string, lang types.Language, kind types.LineKind, n int64),
+	excluded func(kind types.FileKind, lang types.Language, lines map[types.LineKind]int64),
+) {
+	for email, kinds := range p.PerUserLines {
+		for kind, langs := range kinds {
+			for name, n := range langs {
+				if l, ok := lang(name); ok {
+					add(email, l, kind, n)
+				}
+			}
+		}
+	}
+
+	for kind, stats := range p.Excluded {
+		for name, lines := range stats.perLang() {
+			if l, ok := lang(name); ok {
+				excluded(kind, l, lines)
+			}
+		}
+	}
+}
+
+// perLang returns lines of every kind per language name, only code lines are known without Lines.
+func (e ExcludedLines) perLang() map[string]map[types.LineKind]int64 {
+	res := make(map[string]map[types.LineKind]int64, len(e.PerLang))
+
+	if len(e.Lines) == 0 {
+		for name, n := range e.PerLang {
+			res[name] = map[types.LineKind]int64{types.Code: n}
+		}
+
+		return res
+	}
+
+	for kind, langs := range e.Lines {
+		for name, n := range langs {
+			if res[name] == nil {
+				res[name] = make(map[types.LineKind]int64)
+			}
+
+			res[name][kind] = n
+		}
+	}
+
+	return res
+}
+
+// Merge adds projects of other to b replacing the same ones along with their failures.
+func (b *Baseline) Merge(other *Baseline) *Baseline {
+	if b == nil {
+		return other
+	}
+
+	if other == nil {
+		return b
+	}
+
+	if b.Projects == nil {
+		b.Projects = make(map[string]*BaselineProject, len(other.Projects))
+	}
+
+	for project, p := range other.Projects {
+		b.Projects[project] = p
+	}
+
+	b.Failures = slices.DeleteFunc(b.Failures, func(failure types.Failure) bool {
+		_, ok := other.Projects[failure.Project]
+		return ok
+	})
+	b.Failures = append(b.Failures, other.Failures...)
+
+	return b
+}
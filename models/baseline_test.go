@@ -0,0 +1,42 @@
+package models
+
+import (
+	"github.com/gaarutyunov/gitstat/types"
+	"testing"
+)
+
+func TestBaselineProject(t *testing.T) {
+	b := &Baseline{
+		Projects: map[string]*BaselineProject{
+			"acme/app": {Head: "a"},
+			"acme/lib": {Head: "b"},
+		},
+		Failures: []types.Failure{{Project: "acme/lib", Path: "main.go", Class: types.ServerError}},
+	}
+
+	tests := []struct {
+		name    string
+		project string
+		head    string
+		want    bool
+	}{
+		{"unchanged", "acme/app", "a", true},
+		{"moved", "acme/app", "c", false},
+		{"failed file", "acme/lib", "b", false},
+		{"unknown", "acme/web", "a", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := b.Project(tt.project, tt.head); ok != tt.want {
+				t.Errorf("got restored %v, want %v", ok, tt.want)
+			}
+		})
+	}
+
+	b.Merge(&Baseline{Projects: map[string]*BaselineProject{"acme/lib": {Head: "b"}}})
+
+	if _, ok := b.Project("acme/lib", "b"); !ok {
+		t.Errorf("project completed after the failure isn't restored")
+	}
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/types"
+	"io"
+	"slices"
+	"sync"
+)
+
+type (
+	// Checkpoint writes projects as they are completed, one JSON object per line,
+	// so that an interrupted run can be resumed.
+	Checkpoint struct {
+		mx sync.Mutex
+		w  io.Writer
+		// projects is the number of projects written
+		projects int
+	}
+
+	checkpointHeader struct {
+		Args []string `json:"args"`
+	}
+
+	checkpointProject struct {
+		Project string `json:"project"`
+		BaselineProject
+	}
+)
+
+// NewCheckpoint writes the header of a run with args and projects already completed to w.
+func NewCheckpoint(w io.Writer, args []string, completed *Baseline) (*Checkpoint, error) {
+	c := &Checkpoint{w: w}
+
+	if err := c.write(checkpointHeader{Args: args}); err != nil {
+		return nil, err
+	}
+
+	if completed != nil {
+		for project, p := range completed.Projects {
+			if err := c.write(checkpointProject{Project: project, BaselineProject: *p}); err != nil {
+				return nil, err
+			}
+
+			c.projects++
+		}
+	}
+
+	return c, nil
+}
+
+// LoadCheckpoint reads projects completed by a run with args,
+// an incomplete last line left by an interrupted write is ignored.
+func LoadCheckpoint(r io.Reader, args []string) (*Baseline, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("checkpoint is empty")
+	}
+
+	var header checkpointHeader
+
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, errors.Join(errors.New("invalid checkpoint header"), err)
+	}
+
+	if !slices.Equal(header.Args, args) {
+		return nil, fmt.Errorf("checkpoint was written with different flags: %v", header.Args)
+	}
+
+	b := &Baseline{Projects: make(map[string]*BaselineProject)}
+
+	for scanner.Scan() {
+		var p checkpointProject
+
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			break
+		}
+
+		b.Projects[p.Project] = &p.BaselineProject
+	}
+
+	return b, scanner.Err()
+}
+
+// Add writes the lines of project counted by counter, nothing is written to a nil checkpoint.
+func (c *Checkpoint) Add(project string, counter types.ProjectCounter) error {
+	if c == nil {
+		return nil
+	}
+
+	err := c.write(struct {
+		Project string `json:"project"`
+		*ProjectStats
+	}{project, NewProjectStats(counter)})
+	if err != nil {
+		return err
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.projects++
+
+	return nil
+}
+
+// Len returns the number of projects written, a nil checkpoint has none.
+func (c *Checkpoint) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	return c.projects
+}
+
+// write writes v as a single line, lines are written with a single call to stay whole on interruption.
+func (c *Checkpoint) write(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	_, err = c.w.Write(append(data, '\n'))
+
+	return err
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/types"
+	"sync"
+)
+
+type (
+	LanguageCommitCounter struct {
+		total   types.CommitCount
+		perLang map[types.Language]types.CommitCount
+	}
+
+	// CommitCounter is a concurrency safe types.CommitCounter.
+	CommitCounter struct {
+		mx      sync.Mutex
+		total   *LanguageCommitCounter
+		perUser map[types.User]*LanguageCommitCounter
+		perRepo map[string]*LanguageCommitCounter
+	}
+
+	PerLangCommitMap map[types.Language]types.CommitCount
+
+	CommitStatsPerUser map[types.User]types.PerLanguageCommitCounter
+
+	CommitStatsPerRepo map[string]types.PerLanguageCommitCounter
+
+	CommitStats struct {
+		PerLang PerLangCommitMap   `json:"per_lang"`
+		Total   types.CommitCount  `json:"total"`
+		PerUser CommitStatsPerUser `json:"per_user"`
+		PerRepo CommitStatsPerRepo `json:"per_repo"`
+	}
+)
+
+func newLanguageCommitCounter() *LanguageCommitCounter {
+	return &LanguageCommitCounter{perLang: make(map[types.Language]types.CommitCount)}
+}
+
+func (c *LanguageCommitCounter) add(total types.CommitCount, changes map[types.Language]types.CommitCount) {
+	c.total.Commits++
+	c.total.Additions += total.Additions
+	c.total.Deletions += total.Deletions
+
+	for lang, n := range changes {
+		cnt := c.perLang[lang]
+		cnt.Commits++
+		cnt.Additions += n.Additions
+		cnt.Deletions += n.Deletions
+		c.perLang[lang] = cnt
+	}
+}
+
+func (c *LanguageCommitCounter) PerLanguage() map[types.Language]types.CommitCount {
+	return c.perLang
+}
+
+func (c *LanguageCommitCounter) Total() types.CommitCount {
+	return c.total
+}
+
+func NewCommitCounter() *CommitCounter {
+	return &CommitCounter{
+		total:   newLanguageCommitCounter(),
+		perUser: make(map[types.User]*LanguageCommitCounter),
+		perRepo: make(map[string]*LanguageCommitCounter),
+	}
+}
+
+// Add counts a commit of user in repo with additions and deletions of all its files in total
+// and of files in known languages in changes, which is nil if the commit isn't split per language.
+func (c *CommitCounter) Add(repo string, user types.User, total types.CommitCount, changes map[types.Language]types.CommitCount) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if _, ok := c.perUser[user]; !ok {
+		c.perUser[user] = newLanguageCommitCounter()
+	}
+
+	if _, ok := c.perRepo[repo]; !ok {
+		c.perRepo[repo] = newLanguageCommitCounter()
+	}
+
+	c.total.add(total, changes)
+	c.perUser[user].add(total, changes)
+	c.perRepo[repo].add(total, changes)
+}
+
+func (c *CommitCounter) PerUser() map[types.User]types.PerLanguageCommitCounter {
+	res := make(map[types.User]types.PerLanguageCommitCounter, len(c.perUser))
+
+	for user, counter := range c.perUser {
+		res[user] = counter
+	}
+
+	return res
+}
+
+func (c *CommitCounter) PerRepository() map[string]types.PerLanguageCommitCounter {
+	res := make(map[string]types.PerLanguageCommitCounter, len(c.perRepo))
+
+	for repo, counter := range c.perRepo {
+		res[repo] = counter
+	}
+
+	return res
+}
+
+func (c *CommitCounter) PerLanguage() map[types.Language]types.CommitCount {
+	return c.total.PerLanguage()
+}
+
+func (c *CommitCounter) Total() types.CommitCount {
+	return c.total.Total()
+}
+
+func NewCommitStats(c types.CommitCounter) *CommitStats {
+	return &CommitStats{
+		PerLang: c.PerLanguage(),
+		Total:   c.Total(),
+		PerUser: c.PerUser(),
+		PerRepo: c.PerRepository(),
+	}
+}
+
+func (p PerLangCommitMap) MarshalJSON() ([]byte, error) {
+	m := make(map[string]types.CommitCount)
+
+	for k, v := range p {
+		m[k.Name()] = v
+	}
+
+	return json.Marshal(m)
+}
+
+func (s CommitStatsPerUser) MarshalJSON() ([]byte, error) {
+	m := make(map[string]commitStatsPerLang)
+
+	for k, v := range s {
+		m[k.GetEmail()] = newCommitStatsPerLang(v)
+	}
+
+	return json.Marshal(m)
+}
+
+func (s CommitStatsPerRepo) MarshalJSON() ([]byte, error) {
+	m := make(map[string]commitStatsPerLang)
+
+	for k, v := range s {
+		m[k] = newCommitStatsPerLang(v)
+	}
+
+	return json.Marshal(m)
+}
+
+type commitStatsPerLang struct {
+	PerLang PerLangCommitMap  `json:"per_lang"`
+	Total   types.CommitCount `json:"total"`
+}
+
+func newCommitStatsPerLang(c types.PerLanguageCommitCounter) commitStatsPerLang {
+	return commitStatsPerLang{PerLang: c.PerLanguage(), Total: c.Total()}
+}
+
+func formatCommitCount(c types.CommitCount) string {
+	return fmt.Sprintf("%d commits, +%d -%d", c.Commits, c.Additions, c.Deletions)
+}
+
+func (s CommitStats) String() (txt string) {
+	txt += "Commits:\n"
+
+	for k, v := range s.PerLang {
+		txt += fmt.Sprintf("  - %s: %s\n", k.Name(), formatCommitCount(v))
+	}
+
+	txt += fmt.Sprintf("  - Total: %s\n", formatCommitCount(s.Total))
+
+	txt += "Commits per user:\n"
+
+	for user, counter := range s.PerUser {
+		txt += fmt.Sprintf("  - %s:\n", user.GetEmail())
+
+		for language, n := range counter.PerLanguage() {
+			txt += fmt.Sprintf("    - %s: %s\n", language.Name(), formatCommitCount(n))
+		}
+
+		txt += fmt.Sprintf("    - Total: %s\n", formatCommitCount(counter.Total()))
+	}
+
+	txt += "Commits per repository:\n"
+
+	for repo, counter := range s.PerRepo {
+		txt += fmt.Sprintf("  - %s:\n", repo)
+
+		for language, n := range counter.PerLanguage() {
+			txt += fmt.Sprintf("    - %s: %s\n", language.Name(), formatCommitCount(n))
+		}
+
+		txt += fmt.Sprintf("    - Total: %s\n", formatCommitCount(counter.Total()))
+	}
+
+	return
+}
@@ -0,0 +1,206 @@
+package models
+
+import (
+	"github.com/gaarutyunov/gitstat/types"
+	"sync/atomic"
+)
+
+// LineCount counts lines of every kind.
+type LineCount struct {
+	Code    atomic.Int64
+	Comment atomic.Int64
+	Blank   atomic.Int64
+}
+
+func (c *LineCount) Add(kind types.LineKind, n int64) {
+	switch kind {
+	case types.Code:
+		c.Code.Add(n)
+	case types.Comment:
+		c.Comment.Add(n)
+	case types.Blank:
+		c.Blank.Add(n)
+	}
+}
+
+func (c *LineCount) Load(kind types.LineKind) int64 {
+	switch kind {
+	case types.Code:
+		return c.Code.Load()
+	case types.Comment:
+		return c.Comment.Load()
+	case types.Blank:
+		return c.Blank.Load()
+	}
+
+	return 0
+}
+
+// MapLanguageCounter counts lines per language, line counts of the counter interfaces are code lines.
+type MapLanguageCounter map[types.Language]*LineCount
+
+var DefaultUser = NewUser("other", nil)
+
+func MakeMapLanguageCounter(keys []types.Language) types.PerLanguageCounter {
+	m := make(MapLanguageCounter, len(keys))
+
+	for _, key := range keys {
+		m[key] = &LineCount{}
+	}
+
+	return m
+}
+
+// PerLanguage returns counts of languages with any code lines.
+func (m MapLanguageCounter) PerLanguage() (res map[types.Language]int) {
+	res = make(map[types.Language]int)
+
+	for language, counter := range m {
+		if n := counter.Code.Load(); n != 0 {
+			res[language] = int(n)
+		}
+	}
+
+	return
+}
+
+func (m MapLanguageCounter) Total() (total int) {
+	for _, counter := range m {
+		total += int(counter.Code.Load())
+	}
+
+	return
+}
+
+// PerKind returns counts of languages with any lines of the kind.
+func (m MapLanguageCounter) PerKind() (res map[types.LineKind]map[types.Language]int) {
+	res = make(map[types.LineKind]map[types.Language]int, 3)
+
+	for _, kind := range []types.LineKind{types.Code, types.Comment, types.Blank} {
+		res[kind] = make(map[types.Language]int)
+
+		for language, counter := range m {
+			if n := counter.Load(kind); n != 0 {
+				res[kind][language] = int(n)
+			}
+		}
+	}
+
+	return
+}
+
+// AddLines adds the number of lines of every kind in lang.
+func (m MapLanguageCounter) AddLines(lang types.Language, lines map[types.LineKind]int64) {
+	for kind, n := range lines {
+		m[lang].Add(kind, n)
+	}
+}
+
+// MapUserCounter is a types.LineCounter of per language counters of every user.
+type MapUserCounter map[types.User]types.PerLanguageCounter
+
+func (m MapUserCounter) PerUser() (res map[types.User]types.PerLanguageCounter) {
+	res = make(map[types.User]types.PerLanguageCounter, len(m))
+
+	for user, counter := range m {
+		if counter.Total() == 0 {
+			continue
+		}
+
+		res[user] = counter
+	}
+
+	return
+}
+
+// PerUserKind returns counters of users with lines of any kind.
+func (m MapUserCounter) PerUserKind() (res map[types.User]types.PerKindCounter) {
+	res = make(map[types.User]types.PerKindCounter, len(m))
+
+	for user, counter := range m {
+		kinds, ok := counter.(types.PerKindCounter)
+		if !ok {
+			continue
+		}
+
+		for _, langs := range kinds.PerKind() {
+			if len(langs) != 0 {
+				res[user] = kinds
+				break
+			}
+		}
+	}
+
+	return
+}
+
+func (m MapUserCounter) PerLanguage() (res map[types.Language]int) {
+	res = make(map[types.Language]int)
+
+	for _, counter := range m {
+		for lang, n := range counter.PerLanguage() {
+			res[lang] += n
+		}
+	}
+
+	return
+}
+
+func (m MapUserCounter) PerKind() (res map[types.LineKind]map[types.Language]int) {
+	res = make(map[types.LineKind]map[types.Language]int, 3)
+
+	for _, counter := range m {
+		kinds, ok := counter.(types.PerKindCounter)
+		if !ok {
+			continue
+		}
+
+		for kind, langs := range kinds.PerKind() {
+			if res[kind] == nil {
+				res[kind] = make(map[types.Language]int)
+			}
+
+			for lang, n := range langs {
+				res[kind][lang] += n
+			}
+		}
+	}
+
+	return
+}
+
+func (m MapUserCounter) Total() (total int) {
+	for _, counter := range m {
+		total += counter.Total()
+	}
+
+	return
+}
+
+// MapKindCounter counts lines of generated and vendored files per language.
+type MapKindCounter map[types.FileKind]types.PerLanguageCounter
+
+func MakeMapKindCounter(langs []types.Language) MapKindCounter {
+	return MapKindCounter{
+		types.Generated: MakeMapLanguageCounter(langs),
+		types.Vendored:  MakeMapLanguageCounter(langs),
+	}
+}
+
+// Add adds the number of lines of every kind in a file of kind in lang.
+func (m MapKindCounter) Add(kind types.FileKind, lang types.Language, lines map[types.LineKind]int64) {
+	m[kind].(MapLanguageCounter).AddLines(lang, lines)
+}
+
+// NonEmpty returns counters of kinds with any lines.
+func (m MapKindCounter) NonEmpty() (res map[types.FileKind]types.PerLanguageCounter) {
+	res = make(map[types.FileKind]types.PerLanguageCounter)
+
+	for kind, counter := range m {
+		if counter.Total() != 0 {
+			res[kind] = counter
+		}
+	}
+
+	return
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"github.com/gaarutyunov/gitstat/types"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Facts collects files and their blame ranges of projects,
+// a nil collection collects nothing so that collecting can be disabled.
+type Facts struct {
+	mx       sync.Mutex
+	projects map[string]*types.ProjectFacts
+}
+
+func NewFacts() *Facts {
+	return &Facts{projects: make(map[string]*types.ProjectFacts)}
+}
+
+func (f *Facts) project(project string) *types.ProjectFacts {
+	p, ok := f.projects[project]
+	if !ok {
+		p = &types.ProjectFacts{Project: project}
+		f.projects[project] = p
+	}
+
+	return p
+}
+
+// SetHead sets the head commit of project.
+func (f *Facts) SetHead(project, head string) {
+	if f == nil {
+		return
+	}
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	f.project(project).Head = head
+}
+
+// Add adds file of project, its lines are summed up from ranges if there are any.
+func (f *Facts) Add(project string, file types.FileFacts) {
+	if f == nil {
+		return
+	}
+
+	if len(file.Ranges) != 0 {
+		file.Lines = make(map[types.LineKind]int64, 3)
+
+		for _, r := range file.Ranges {
+			for kind, n := range r.Lines {
+				file.Lines[kind] += n
+			}
+		}
+	}
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	p := f.project(project)
+	p.Files = append(p.Files, file)
+}
+
+// List returns projects sorted by path with their files sorted by path.
+func (f *Facts) List() []types.ProjectFacts {
+	if f == nil {
+		return nil
+	}
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	res := make([]types.ProjectFacts, 0, len(f.projects))
+
+	for _, p := range f.projects {
+		p := *p
+		p.Files = slices.Clone(p.Files)
+
+		slices.SortFunc(p.Files, func(a, b types.FileFacts) int {
+			return strings.Compare(a.Path, b.Path)
+		})
+
+		res = append(res, p)
+	}
+
+	slices.SortFunc(res, func(a, b types.ProjectFacts) int {
+		return strings.Compare(a.Project, b.Project)
+	})
+
+	return res
+}
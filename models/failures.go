@@ -0,0 +1,146 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/types"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type (
+	// Failures collects failures of concurrently processed projects and files.
+	Failures struct {
+		mx       sync.Mutex
+		failures []types.Failure
+	}
+
+	// FailureReport is the list of failures with a summary for the text output.
+	FailureReport []types.Failure
+)
+
+// failureExamples is the number of failures listed in the text output.
+const failureExamples = 20
+
+// NewFailure classifies err of project or path in it by the HTTP status if any.
+func NewFailure(project, path string, status int, err error) types.Failure {
+	return types.Failure{
+		Project: project,
+		Path:    path,
+		Status:  status,
+		Class:   Classify(status, err),
+		Error:   err.Error(),
+	}
+}
+
+// Classify returns the class of err with HTTP status, 0 if there was no response.
+func Classify(status int, err error) types.ErrorClass {
+	var netErr net.Error
+
+	switch {
+	case status == http.StatusNotFound:
+		return types.NotFound
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return types.Forbidden
+	case status == http.StatusTooManyRequests:
+		return types.RateLimited
+	case status >= http.StatusInternalServerError:
+		return types.ServerError
+	case status >= http.StatusBadRequest:
+		return types.ClientError
+	case errors.Is(err, context.DeadlineExceeded):
+		return types.Timeout
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return types.Timeout
+		}
+		return types.NetworkError
+	default:
+		return types.OtherError
+	}
+}
+
+func (f *Failures) Add(failure types.Failure) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	f.failures = append(f.failures, failure)
+}
+
+// Has reports whether project or any of its files failed.
+func (f *Failures) Has(project string) bool {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	return slices.ContainsFunc(f.failures, func(failure types.Failure) bool {
+		return failure.Project == project
+	})
+}
+
+// List returns failures sorted by project and path.
+func (f *Failures) List() []types.Failure {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	res := slices.Clone(f.failures)
+
+	slices.SortFunc(res, func(a, b types.Failure) int {
+		if c := strings.Compare(a.Project, b.Project); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return res
+}
+
+// Summary returns the number of failed projects and files.
+func (r FailureReport) Summary() string {
+	var projects, files int
+
+	for _, failure := range r {
+		if failure.Path == "" {
+			projects++
+		} else {
+			files++
+		}
+	}
+
+	return fmt.Sprintf("%d projects and %d files failed", projects, files)
+}
+
+func (r FailureReport) String() (txt string) {
+	txt += fmt.Sprintf("Failures: %s\n", r.Summary())
+
+	classes := make(map[types.ErrorClass]int)
+
+	for _, failure := range r {
+		classes[failure.Class]++
+	}
+
+	for _, class := range slices.Sorted(maps.Keys(classes)) {
+		txt += fmt.Sprintf("  - %s: %d\n", class, classes[class])
+	}
+
+	for i, failure := range r {
+		if i == failureExamples {
+			txt += fmt.Sprintf("  ... %d more\n", len(r)-i)
+			break
+		}
+
+		name := failure.Project
+		if failure.Path != "" {
+			name += ":" + failure.Path
+		}
+
+		txt += fmt.Sprintf("  - %s: %s\n", name, strings.ReplaceAll(failure.Error, "\n", ": "))
+	}
+
+	return
+}
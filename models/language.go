@@ -6,8 +6,9 @@ import (
 )
 
 type Language struct {
-	name string
-	ext  []string
+	name     string
+	ext      []string
+	comments types.Comments
 }
 
 func (l *Language) Name() string {
@@ -18,6 +19,10 @@ func (l *Language) Ext() []string {
 	return l.ext
 }
 
+func (l *Language) Comments() types.Comments {
+	return l.comments
+}
+
 func NewLanguage(name string, ext []string) types.Language {
 	for i, s := range ext {
 		if !strings.HasPrefix(s, ".") {
@@ -26,3 +31,10 @@ func NewLanguage(name string, ext []string) types.Language {
 	}
 	return &Language{name: name, ext: ext}
 }
+
+// NewLanguageWithComments creates a language which lines are classified by the comment syntax.
+func NewLanguageWithComments(name string, ext []string, comments types.Comments) types.Language {
+	lang := NewLanguage(name, ext).(*Language)
+	lang.comments = comments
+	return lang
+}
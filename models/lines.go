@@ -0,0 +1,148 @@
+package models
+
+import (
+	"github.com/gaarutyunov/gitstat/types"
+	"strings"
+)
+
+// LineScanner classifies consecutive lines of a file as code, comment or blank lines.
+type LineScanner struct {
+	comments types.Comments
+	// end is the end marker of the open block comment
+	end string
+	// quote is the open multiline literal
+	quote *types.Quote
+}
+
+func NewLineScanner(lang types.Language) *LineScanner {
+	return &LineScanner{comments: lang.Comments()}
+}
+
+// Scan returns the kind of the next line of the file.
+// Lines with both code and comments are code lines, blank lines in block comments are blank.
+// Literals are code, so comment markers inside them don't start comments.
+func (s *LineScanner) Scan(line string) types.LineKind {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return types.Blank
+	}
+
+	code := false
+
+	for line != "" {
+		if s.end != "" {
+			i := strings.Index(line, s.end)
+			if i < 0 {
+				break
+			}
+
+			line = strings.TrimSpace(line[i+len(s.end):])
+			s.end = ""
+
+			continue
+		}
+
+		if s.quote != nil {
+			code = true
+
+			i := closing(line, *s.quote)
+			if i < 0 {
+				break
+			}
+
+			line = line[i:]
+			s.quote = nil
+
+			continue
+		}
+
+		start, end, quote, i := s.next(line)
+
+		if i < 0 {
+			code = true
+			break
+		}
+
+		if i > 0 {
+			code = true
+		}
+
+		if quote != nil {
+			line = line[i+len(start):]
+			s.quote = quote
+
+			continue
+		}
+
+		if end == "" {
+			break
+		}
+
+		line = line[i+len(start):]
+		s.end = end
+	}
+
+	if s.quote != nil && !s.quote.Multiline {
+		s.quote = nil
+	}
+
+	if code {
+		return types.Code
+	}
+
+	return types.Comment
+}
+
+// next finds the first comment marker or literal delimiter in line returning the end marker for block comments
+// and the quote for literals. The longest marker wins at the same position, comments win over literals.
+func (s *LineScanner) next(line string) (start, end string, quote *types.Quote, pos int) {
+	pos = -1
+
+	for _, marker := range s.comments.Line {
+		if i := strings.Index(line, marker); i >= 0 && (pos < 0 || i < pos) {
+			start, end, pos = marker, "", i
+		}
+	}
+
+	for _, markers := range s.comments.Block {
+		if i := strings.Index(line, markers[0]); i >= 0 && (pos < 0 || i < pos || i == pos && len(markers[0]) > len(start)) {
+			start, end, pos = markers[0], markers[1], i
+		}
+	}
+
+	for j, q := range s.comments.Strings {
+		if i := strings.Index(line, q.Delimiter); i >= 0 && (pos < 0 || i < pos || i == pos && len(q.Delimiter) > len(start)) {
+			start, end, quote, pos = q.Delimiter, "", &s.comments.Strings[j], i
+		}
+	}
+
+	return
+}
+
+// closing returns the position after the delimiter closing a literal of q in line, -1 if it isn't closed.
+func closing(line string, q types.Quote) int {
+	for i := 0; i < len(line); i++ {
+		if !q.Raw && line[i] == '\\' {
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(line[i:], q.Delimiter) {
+			return i + len(q.Delimiter)
+		}
+	}
+
+	return -1
+}
+
+// CountLines counts code, comment and blank lines of a file in lang.
+func CountLines(lang types.Language, lines []string) (res map[types.LineKind]int64) {
+	res = make(map[types.LineKind]int64, 3)
+	scanner := NewLineScanner(lang)
+
+	for _, line := range lines {
+		res[scanner.Scan(line)]++
+	}
+
+	return
+}
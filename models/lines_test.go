@@ -0,0 +1,57 @@
+package models
+
+import (
+	"github.com/gaarutyunov/gitstat/types"
+	"testing"
+)
+
+func TestLineScanner(t *testing.T) {
+	quotes := []types.Quote{{Delimiter: `"`}, {Delimiter: "'"}}
+	golang := NewLanguageWithComments("Go", nil, types.Comments{
+		Line:    []string{"//"},
+		Block:   [][2]string{{"/*", "*/"}},
+		Strings: append([]types.Quote{{Delimiter: "`", Raw: true, Multiline: true}}, quotes...),
+	})
+	python := NewLanguageWithComments("Python", nil, types.Comments{
+		Line:    []string{"#"},
+		Block:   [][2]string{{`"""`, `"""`}, {"'''", "'''"}},
+		Strings: quotes,
+	})
+
+	const (
+		code    = types.Code
+		comment = types.Comment
+		blank   = types.Blank
+	)
+
+	tests := []struct {
+		name  string
+		lang  types.Language
+		lines []string
+		want  []types.LineKind
+	}{
+		{"block comment", golang, []string{"/* a", "", "b */", "x := 1 /* c */"}, []types.LineKind{comment, blank, comment, code}},
+		{"block marker in string", golang, []string{`s := "/*"`, "x := 1"}, []types.LineKind{code, code}},
+		{"line marker in string", golang, []string{`fmt.Println("// not a comment")`, "// comment"}, []types.LineKind{code, comment}},
+		{"escaped quote", golang, []string{`s := "\"/*"`, "y := 2"}, []types.LineKind{code, code}},
+		{"rune", golang, []string{`c := '"' // quote`, "// comment"}, []types.LineKind{code, comment}},
+		{"raw string", golang, []string{"q := `", "// inside", "", "/* still */", "`", "// comment"}, []types.LineKind{code, code, blank, code, code, comment}},
+		{"raw string without escapes", golang, []string{"q := `\\`", "// comment"}, []types.LineKind{code, comment}},
+		{"unclosed string", golang, []string{`s := "abc`, "/* c */"}, []types.LineKind{code, comment}},
+		{"comment after string", golang, []string{`s := "a" /* c`, "d */"}, []types.LineKind{code, comment}},
+		{"hash in string", python, []string{`x = "#"`, "# comment", `y = '#' # c`}, []types.LineKind{code, comment, code}},
+		{"docstring", python, []string{`"""doc"""`, `"""`, "text", `"""`}, []types.LineKind{comment, comment, comment, comment}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewLineScanner(tt.lang)
+
+			for i, line := range tt.lines {
+				if got := scanner.Scan(line); got != tt.want[i] {
+					t.Errorf("line %q is %s, want %s", line, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
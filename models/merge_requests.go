@@ -0,0 +1,193 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/types"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+type (
+	mergeRequestCount struct {
+		types.MergeRequestCount
+		timesToMerge []time.Duration
+	}
+
+	// MergeRequestCounter is a concurrency safe types.MergeRequestCounter.
+	MergeRequestCounter struct {
+		mx      sync.Mutex
+		total   *mergeRequestCount
+		perUser map[types.User]*mergeRequestCount
+	}
+
+	MergeRequestStatsPerUser map[types.User]types.MergeRequestCount
+
+	MergeRequestStats struct {
+		Total   types.MergeRequestCount  `json:"total"`
+		PerUser MergeRequestStatsPerUser `json:"per_user"`
+	}
+
+	mergeRequestCountJSON struct {
+		Opened                   int     `json:"opened"`
+		Merged                   int     `json:"merged"`
+		Closed                   int     `json:"closed"`
+		MedianTimeToMergeSeconds float64 `json:"median_time_to_merge_seconds"`
+		P90TimeToMergeSeconds    float64 `json:"p90_time_to_merge_seconds"`
+		Approvals                int     `json:"approvals"`
+		Comments                 int     `json:"comments"`
+	}
+)
+
+func NewMergeRequestCounter() *MergeRequestCounter {
+	return &MergeRequestCounter{
+		total:   &mergeRequestCount{},
+		perUser: make(map[types.User]*mergeRequestCount),
+	}
+}
+
+func (c *MergeRequestCounter) add(user types.User, f func(n *mergeRequestCount)) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if _, ok := c.perUser[user]; !ok {
+		c.perUser[user] = &mergeRequestCount{}
+	}
+
+	f(c.total)
+	f(c.perUser[user])
+}
+
+// Opened counts a merge request opened by user.
+func (c *MergeRequestCounter) Opened(user types.User) {
+	c.add(user, func(n *mergeRequestCount) {
+		n.Opened++
+	})
+}
+
+// Merged counts a merge request of user merged after timeToMerge since it was opened.
+func (c *MergeRequestCounter) Merged(user types.User, timeToMerge time.Duration) {
+	c.add(user, func(n *mergeRequestCount) {
+		n.Merged++
+		n.timesToMerge = append(n.timesToMerge, timeToMerge)
+	})
+}
+
+// Closed counts a merge request of user closed without merging.
+func (c *MergeRequestCounter) Closed(user types.User) {
+	c.add(user, func(n *mergeRequestCount) {
+		n.Closed++
+	})
+}
+
+// Approved counts an approval given by user.
+func (c *MergeRequestCounter) Approved(user types.User) {
+	c.add(user, func(n *mergeRequestCount) {
+		n.Approvals++
+	})
+}
+
+// Commented counts a comment left by user on a merge request of another user.
+func (c *MergeRequestCounter) Commented(user types.User) {
+	c.add(user, func(n *mergeRequestCount) {
+		n.Comments++
+	})
+}
+
+func (n *mergeRequestCount) count() types.MergeRequestCount {
+	res := n.MergeRequestCount
+
+	if len(n.timesToMerge) != 0 {
+		sorted := slices.Clone(n.timesToMerge)
+		slices.Sort(sorted)
+
+		res.MedianTimeToMerge = percentile(sorted, 0.5)
+		res.P90TimeToMerge = percentile(sorted, 0.9)
+	}
+
+	return res
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+
+	return sorted[max(rank, 0)]
+}
+
+func (c *MergeRequestCounter) PerUser() map[types.User]types.MergeRequestCount {
+	res := make(map[types.User]types.MergeRequestCount, len(c.perUser))
+
+	for user, n := range c.perUser {
+		res[user] = n.count()
+	}
+
+	return res
+}
+
+func (c *MergeRequestCounter) Total() types.MergeRequestCount {
+	return c.total.count()
+}
+
+func NewMergeRequestStats(c types.MergeRequestCounter) *MergeRequestStats {
+	return &MergeRequestStats{
+		Total:   c.Total(),
+		PerUser: c.PerUser(),
+	}
+}
+
+func newMergeRequestCountJSON(n types.MergeRequestCount) mergeRequestCountJSON {
+	return mergeRequestCountJSON{
+		Opened:                   n.Opened,
+		Merged:                   n.Merged,
+		Closed:                   n.Closed,
+		MedianTimeToMergeSeconds: n.MedianTimeToMerge.Seconds(),
+		P90TimeToMergeSeconds:    n.P90TimeToMerge.Seconds(),
+		Approvals:                n.Approvals,
+		Comments:                 n.Comments,
+	}
+}
+
+func (s MergeRequestStats) MarshalJSON() ([]byte, error) {
+	m := make(map[string]mergeRequestCountJSON, len(s.PerUser))
+
+	for k, v := range s.PerUser {
+		m[k.GetEmail()] = newMergeRequestCountJSON(v)
+	}
+
+	return json.Marshal(struct {
+		Total   mergeRequestCountJSON            `json:"total"`
+		PerUser map[string]mergeRequestCountJSON `json:"per_user"`
+	}{
+		Total:   newMergeRequestCountJSON(s.Total),
+		PerUser: m,
+	})
+}
+
+func formatMergeRequestCount(indent string, n types.MergeRequestCount) (txt string) {
+	txt += fmt.Sprintf("%s- Opened: %d\n", indent, n.Opened)
+	txt += fmt.Sprintf("%s- Merged: %d\n", indent, n.Merged)
+	txt += fmt.Sprintf("%s- Closed: %d\n", indent, n.Closed)
+	txt += fmt.Sprintf("%s- Median time to merge: %s\n", indent, n.MedianTimeToMerge.Round(time.Minute))
+	txt += fmt.Sprintf("%s- P90 time to merge: %s\n", indent, n.P90TimeToMerge.Round(time.Minute))
+	txt += fmt.Sprintf("%s- Approvals: %d\n", indent, n.Approvals)
+	txt += fmt.Sprintf("%s- Comments: %d\n", indent, n.Comments)
+
+	return
+}
+
+func (s MergeRequestStats) String() (txt string) {
+	txt += "Merge requests:\n"
+	txt += formatMergeRequestCount("  ", s.Total)
+
+	txt += "Merge requests per user:\n"
+
+	for user, n := range s.PerUser {
+		txt += fmt.Sprintf("  - %s:\n", user.GetEmail())
+		txt += formatMergeRequestCount("    ", n)
+	}
+
+	return
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/types"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metricsLabelEscaper escapes label values in the OpenMetrics text format.
+var metricsLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricsWriter writes metric families in the OpenMetrics text format.
+type metricsWriter struct {
+	*bufio.Writer
+}
+
+// family writes the type and help of the gauge name.
+func (m *metricsWriter) family(name, help string) {
+	fmt.Fprintf(m, "# TYPE %s gauge\n# HELP %s %s\n", name, name, help)
+}
+
+// sample writes a sample of name with labels given as name and value pairs.
+func (m *metricsWriter) sample(name string, value float64, labels ...string) {
+	m.WriteString(name)
+
+	if len(labels) != 0 {
+		m.WriteByte('{')
+
+		for i := 0; i < len(labels); i += 2 {
+			if i != 0 {
+				m.WriteByte(',')
+			}
+
+			fmt.Fprintf(m, `%s="%s"`, labels[i], metricsLabelEscaper.Replace(labels[i+1]))
+		}
+
+		m.WriteByte('}')
+	}
+
+	fmt.Fprintf(m, " %s\n", strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// WriteOpenMetrics writes the statistics as OpenMetrics gauges for the run that took duration.
+// Lines are per project if the backend counts them, the project label is empty otherwise.
+func (s Stats) WriteOpenMetrics(w io.Writer, duration time.Duration) error {
+	m := &metricsWriter{bufio.NewWriter(w)}
+
+	projects := s.Projects
+	if len(projects) == 0 {
+		projects = StatsPerProject{"": {LineStats: s.LineStats, Excluded: s.Excluded}}
+	}
+
+	m.family("gitstat_lines", "Code lines last changed by the user.")
+
+	for _, project := range slices.Sorted(maps.Keys(projects)) {
+		stats := projects[project]
+
+		for _, user := range sortedUsers(stats.PerUser) {
+			perLang := stats.PerUser[user].PerLanguage()
+
+			for _, language := range sortedLanguages(perLang) {
+				m.sample("gitstat_lines", float64(perLang[language]),
+					"user", user.GetEmail(), "language", language.Name(), "project", project)
+			}
+		}
+	}
+
+	if s.Lines != nil {
+		m.family("gitstat_lines_by_kind", "Code, comment and blank lines last changed by the user.")
+
+		for _, project := range slices.Sorted(maps.Keys(projects)) {
+			stats := projects[project]
+
+			for _, user := range sortedUsers(stats.PerUser) {
+				lines := stats.PerUserLines[user.GetEmail()]
+
+				for _, kind := range []types.LineKind{types.Code, types.Comment, types.Blank} {
+					for _, language := range sortedLanguages(lines[kind]) {
+						m.sample("gitstat_lines_by_kind", float64(lines[kind][language]),
+							"user", user.GetEmail(), "language", language.Name(), "project", project, "kind", string(kind))
+					}
+				}
+			}
+		}
+	}
+
+	if len(s.Excluded) != 0 {
+		m.family("gitstat_excluded_lines", "Lines of generated and vendored files.")
+
+		for _, project := range slices.Sorted(maps.Keys(projects)) {
+			excluded := projects[project].Excluded
+
+			for _, kind := range slices.Sorted(maps.Keys(excluded)) {
+				perLang := excluded[kind].PerLang
+
+				for _, language := range sortedLanguages(perLang) {
+					m.sample("gitstat_excluded_lines", float64(perLang[language]),
+						"kind", string(kind), "language", language.Name(), "project", project)
+				}
+			}
+		}
+	}
+
+	if s.Commits != nil {
+		for _, metric := range []struct {
+			name, help string
+			value      func(n types.CommitCount) int
+		}{
+			{"gitstat_commits", "Commits of the user.", func(n types.CommitCount) int { return n.Commits }},
+			{"gitstat_added_lines", "Lines added by the user.", func(n types.CommitCount) int { return n.Additions }},
+			{"gitstat_deleted_lines", "Lines deleted by the user.", func(n types.CommitCount) int { return n.Deletions }},
+		} {
+			m.family(metric.name, metric.help)
+
+			for _, user := range sortedUsers(s.Commits.PerUser) {
+				perLang := s.Commits.PerUser[user].PerLanguage()
+
+				for _, language := range sortedLanguages(perLang) {
+					m.sample(metric.name, float64(metric.value(perLang[language])),
+						"user", user.GetEmail(), "language", language.Name())
+				}
+			}
+		}
+	}
+
+	if s.MergeRequests != nil {
+		m.family("gitstat_merge_requests", "Merge requests of the user by state.")
+
+		for _, user := range sortedUsers(s.MergeRequests.PerUser) {
+			n := s.MergeRequests.PerUser[user]
+
+			m.sample("gitstat_merge_requests", float64(n.Opened), "user", user.GetEmail(), "state", "opened")
+			m.sample("gitstat_merge_requests", float64(n.Merged), "user", user.GetEmail(), "state", "merged")
+			m.sample("gitstat_merge_requests", float64(n.Closed), "user", user.GetEmail(), "state", "closed")
+		}
+
+		m.family("gitstat_merge_request_approvals", "Merge request approvals of the user.")
+
+		for _, user := range sortedUsers(s.MergeRequests.PerUser) {
+			m.sample("gitstat_merge_request_approvals", float64(s.MergeRequests.PerUser[user].Approvals), "user", user.GetEmail())
+		}
+
+		m.family("gitstat_merge_request_comments", "Merge request comments of the user.")
+
+		for _, user := range sortedUsers(s.MergeRequests.PerUser) {
+			m.sample("gitstat_merge_request_comments", float64(s.MergeRequests.PerUser[user].Comments), "user", user.GetEmail())
+		}
+	}
+
+	failures := make(map[types.ErrorClass]int)
+
+	for _, failure := range s.Failures {
+		failures[failure.Class]++
+	}
+
+	m.family("gitstat_failures", "Projects and files which failed to be processed by error class.")
+
+	for _, class := range slices.Sorted(maps.Keys(failures)) {
+		m.sample("gitstat_failures", float64(failures[class]), "class", string(class))
+	}
+
+	m.family("gitstat_run_duration_seconds", "Duration of the run.")
+	m.sample("gitstat_run_duration_seconds", duration.Seconds())
+
+	m.WriteString("# EOF\n")
+
+	return m.Flush()
+}
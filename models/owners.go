@@ -0,0 +1,107 @@
+package models
+
+import (
+	"github.com/gaarutyunov/gitstat/types"
+	"maps"
+	"sync"
+)
+
+type (
+	// FileOwners counts code lines per user of every file of a project.
+	FileOwners struct {
+		mx         sync.Mutex
+		files      map[string]map[types.User]int
+		codeowners string
+		content    []byte
+	}
+
+	// OwnerCounters are file owners of projects by their path,
+	// a nil collection counts nothing so that counting can be disabled.
+	OwnerCounters struct {
+		mx       sync.Mutex
+		projects map[string]*FileOwners
+	}
+)
+
+func NewOwnerCounters() *OwnerCounters {
+	return &OwnerCounters{projects: make(map[string]*FileOwners)}
+}
+
+// Get returns the file owners of project creating them if needed, nil is returned by a nil collection.
+func (c *OwnerCounters) Get(project string) *FileOwners {
+	if c == nil {
+		return nil
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	owners, ok := c.projects[project]
+	if !ok {
+		owners = &FileOwners{files: make(map[string]map[types.User]int)}
+		c.projects[project] = owners
+	}
+
+	return owners
+}
+
+func (c *OwnerCounters) PerProject() map[string]types.FileOwnerCounter {
+	if c == nil {
+		return nil
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	res := make(map[string]types.FileOwnerCounter, len(c.projects))
+
+	for project, owners := range c.projects {
+		res[project] = owners
+	}
+
+	return res
+}
+
+// Add counts n code lines of the file at path last changed by user.
+func (o *FileOwners) Add(path string, user types.User, n int) {
+	if o == nil || n == 0 {
+		return
+	}
+
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	users, ok := o.files[path]
+	if !ok {
+		users = make(map[types.User]int)
+		o.files[path] = users
+	}
+
+	users[user] += n
+}
+
+// SetCodeowners remembers the CODEOWNERS file of the project found at path.
+func (o *FileOwners) SetCodeowners(path string, content []byte) {
+	if o == nil {
+		return
+	}
+
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	o.codeowners, o.content = path, content
+}
+
+func (o *FileOwners) PerFile() map[string]map[types.User]int {
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	return maps.Clone(o.files)
+}
+
+func (o *FileOwners) Codeowners() (string, []byte) {
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	return o.codeowners, o.content
+}
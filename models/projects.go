@@ -0,0 +1,163 @@
+package models
+
+import (
+	"github.com/gaarutyunov/gitstat/types"
+	"sync"
+)
+
+type (
+	// ProjectCounter counts lines of a single project, counters are created lazily
+	// since most users only contribute to a few projects.
+	ProjectCounter struct {
+		mx       sync.RWMutex
+		head     string
+		counter  MapUserCounter
+		excluded MapKindCounter
+	}
+
+	// ProjectCounters are counters of projects by their path.
+	ProjectCounters struct {
+		mx       sync.Mutex
+		projects map[string]*ProjectCounter
+	}
+)
+
+func NewProjectCounters() *ProjectCounters {
+	return &ProjectCounters{projects: make(map[string]*ProjectCounter)}
+}
+
+// Get returns the counter of project creating it if needed.
+func (c *ProjectCounters) Get(project string) *ProjectCounter {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	counter, ok := c.projects[project]
+	if !ok {
+		counter = &ProjectCounter{
+			counter:  make(MapUserCounter),
+			excluded: make(MapKindCounter),
+		}
+		c.projects[project] = counter
+	}
+
+	return counter
+}
+
+// Find returns the counter of project if it was created.
+func (c *ProjectCounters) Find(project string) (*ProjectCounter, bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	counter, ok := c.projects[project]
+
+	return counter, ok
+}
+
+func (c *ProjectCounters) PerProject() map[string]types.ProjectCounter {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	res := make(map[string]types.ProjectCounter, len(c.projects))
+
+	for project, counter := range c.projects {
+		res[project] = counter
+	}
+
+	return res
+}
+
+func (c *ProjectCounter) SetHead(head string) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.head = head
+}
+
+func (c *ProjectCounter) Head() string {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	return c.head
+}
+
+// Add counts n lines of kind in lang last changed by user.
+func (c *ProjectCounter) Add(user types.User, lang types.Language, kind types.LineKind, n int64) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	counter, ok := c.counter[user]
+	if !ok {
+		counter = make(MapLanguageCounter)
+		c.counter[user] = counter
+	}
+
+	lineCounter(counter.(MapLanguageCounter), lang).Add(kind, n)
+}
+
+// AddExcluded adds the number of lines of every kind in a file of kind in lang.
+func (c *ProjectCounter) AddExcluded(kind types.FileKind, lang types.Language, lines map[types.LineKind]int64) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	counter, ok := c.excluded[kind]
+	if !ok {
+		counter = make(MapLanguageCounter)
+		c.excluded[kind] = counter
+	}
+
+	for lineKind, n := range lines {
+		lineCounter(counter.(MapLanguageCounter), lang).Add(lineKind, n)
+	}
+}
+
+func (c *ProjectCounter) PerUser() map[types.User]types.PerLanguageCounter {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	return c.counter.PerUser()
+}
+
+func (c *ProjectCounter) PerUserKind() map[types.User]types.PerKindCounter {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	return c.counter.PerUserKind()
+}
+
+func (c *ProjectCounter) PerLanguage() map[types.Language]int {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	return c.counter.PerLanguage()
+}
+
+func (c *ProjectCounter) PerKind() map[types.LineKind]map[types.Language]int {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	return c.counter.PerKind()
+}
+
+func (c *ProjectCounter) Total() int {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	return c.counter.Total()
+}
+
+func (c *ProjectCounter) Excluded() map[types.FileKind]types.PerLanguageCounter {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	return c.excluded.NonEmpty()
+}
+
+func lineCounter(m MapLanguageCounter, lang types.Language) *LineCount {
+	counter, ok := m[lang]
+	if !ok {
+		counter = &LineCount{}
+		m[lang] = counter
+	}
+
+	return counter
+}
@@ -1,9 +1,13 @@
 package models
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"github.com/gaarutyunov/gitstat/types"
+	"maps"
+	"slices"
+	"strings"
 )
 
 type (
@@ -16,20 +20,184 @@ type (
 
 	StatsPerUser map[types.User]types.PerLanguageCounter
 
-	Stats struct {
+	// LinesPerKind is the number of lines of every kind per language.
+	LinesPerKind map[types.LineKind]PerLangMap
+
+	LineStats struct {
+		StatsPerLang
+		Lines        LinesPerKind            `json:"lines,omitempty"`
+		PerUser      StatsPerUser            `json:"per_user"`
+		PerUserLines map[string]LinesPerKind `json:"per_user_lines,omitempty"`
+	}
+
+	StatsPerGroup map[string]*LineStats
+
+	// ExcludedLineStats are lines of excluded files of a kind.
+	ExcludedLineStats struct {
 		StatsPerLang
-		PerUser StatsPerUser `json:"per_user"`
+		Lines LinesPerKind `json:"lines,omitempty"`
+	}
+
+	StatsPerKind map[types.FileKind]*ExcludedLineStats
+
+	// ProjectStats are lines of a project at its head commit.
+	ProjectStats struct {
+		Head string `json:"head"`
+		LineStats
+		Excluded StatsPerKind `json:"excluded,omitempty"`
+	}
+
+	StatsPerProject map[string]*ProjectStats
+
+	Stats struct {
+		LineStats
+		PerGroup      StatsPerGroup      `json:"per_group,omitempty"`
+		Excluded      StatsPerKind       `json:"excluded,omitempty"`
+		Projects      StatsPerProject    `json:"projects,omitempty"`
+		Commits       *CommitStats       `json:"commits,omitempty"`
+		MergeRequests *MergeRequestStats `json:"merge_requests,omitempty"`
+		Failures      FailureReport      `json:"failures,omitempty"`
 	}
 )
 
 func NewStats(g types.Stats) *Stats {
-	return &Stats{
+	stats := &Stats{
+		LineStats: *NewLineStats(g),
+	}
+
+	if gs, ok := g.(types.GroupStats); ok {
+		if perGroup := gs.PerGroup(); perGroup != nil {
+			stats.PerGroup = make(StatsPerGroup, len(perGroup))
+
+			for group, counter := range perGroup {
+				stats.PerGroup[group] = NewLineStats(counter)
+			}
+		}
+	}
+
+	if e, ok := g.(types.ExcludedStats); ok {
+		stats.Excluded = NewStatsPerKind(e)
+	}
+
+	if p, ok := g.(types.ProjectStats); ok {
+		if perProject := p.PerProject(); len(perProject) != 0 {
+			stats.Projects = make(StatsPerProject, len(perProject))
+
+			for project, counter := range perProject {
+				stats.Projects[project] = NewProjectStats(counter)
+			}
+		}
+	}
+
+	if c, ok := g.(types.CommitStats); ok {
+		if commits := c.Commits(); commits != nil {
+			stats.Commits = NewCommitStats(commits)
+		}
+	}
+
+	if m, ok := g.(types.MergeRequestStats); ok {
+		if mergeRequests := m.MergeRequests(); mergeRequests != nil {
+			stats.MergeRequests = NewMergeRequestStats(mergeRequests)
+		}
+	}
+
+	if f, ok := g.(types.FailureStats); ok {
+		stats.Failures = f.Failures()
+	}
+
+	return stats
+}
+
+func NewLineStats(c types.LineCounter) *LineStats {
+	stats := &LineStats{
 		StatsPerLang: StatsPerLang{
-			PerLang: g.PerLanguage(),
-			Total:   g.Total(),
+			PerLang: c.PerLanguage(),
+			Total:   c.Total(),
 		},
-		PerUser: g.PerUser(),
+		PerUser: c.PerUser(),
+	}
+
+	if k, ok := c.(types.PerKindCounter); ok {
+		stats.Lines = NewLinesPerKind(k)
+		stats.PerUserLines = make(map[string]LinesPerKind, len(stats.PerUser))
+
+		perUser := make(map[types.User]types.PerKindCounter, len(stats.PerUser))
+
+		// users with only comment or blank lines are kept for baselines to restore them
+		if u, ok := c.(types.PerUserKindCounter); ok {
+			perUser = u.PerUserKind()
+		} else {
+			for user, counter := range stats.PerUser {
+				if k, ok := counter.(types.PerKindCounter); ok {
+					perUser[user] = k
+				}
+			}
+		}
+
+		for user, counter := range perUser {
+			stats.PerUserLines[user.GetEmail()] = NewLinesPerKind(counter)
+		}
+	}
+
+	return stats
+}
+
+func NewProjectStats(c types.ProjectCounter) *ProjectStats {
+	return &ProjectStats{
+		Head:      c.Head(),
+		LineStats: *NewLineStats(c),
+		Excluded:  NewStatsPerKind(c),
+	}
+}
+
+// NewStatsPerKind returns lines of excluded files, nil is returned if there are none.
+func NewStatsPerKind(e types.ExcludedStats) StatsPerKind {
+	excluded := e.Excluded()
+	if len(excluded) == 0 {
+		return nil
+	}
+
+	res := make(StatsPerKind, len(excluded))
+
+	for kind, counter := range excluded {
+		res[kind] = &ExcludedLineStats{
+			StatsPerLang: StatsPerLang{
+				PerLang: counter.PerLanguage(),
+				Total:   counter.Total(),
+			},
+		}
+
+		if k, ok := counter.(types.PerKindCounter); ok {
+			res[kind].Lines = NewLinesPerKind(k)
+		}
+	}
+
+	return res
+}
+
+// NewLinesPerKind returns the lines of c, nil is returned if there are none.
+func NewLinesPerKind(c types.PerKindCounter) LinesPerKind {
+	kinds := c.PerKind()
+	if kinds == nil {
+		return nil
+	}
+
+	res := make(LinesPerKind, len(kinds))
+
+	for kind, langs := range kinds {
+		res[kind] = langs
+	}
+
+	return res
+}
+
+// describe returns comment and blank lines of lang for the text output.
+func (l LinesPerKind) describe(lang types.Language) string {
+	if l == nil {
+		return ""
 	}
+
+	return fmt.Sprintf(" (comment: %d, blank: %d)", l[types.Comment][lang], l[types.Blank][lang])
 }
 
 func (s StatsPerUser) MarshalJSON() ([]byte, error) {
@@ -60,13 +228,19 @@ func (p PerLangMap) MarshalJSON() ([]byte, error) {
 
 func (s Stats) String() (txt string) {
 	if s.Total == 0 {
-		return "Empty statistics, try changing --query, --lang or --user"
+		txt = "Empty statistics, try changing --query, --lang or --user"
+
+		if len(s.Failures) != 0 {
+			txt += "\n" + s.Failures.String()
+		}
+
+		return
 	}
 
 	txt += "Languages:\n"
 
 	for k, v := range s.PerLang {
-		txt += fmt.Sprintf("  - %s: %d\n", k.Name(), v)
+		txt += fmt.Sprintf("  - %s: %d%s\n", k.Name(), v, s.Lines.describe(k))
 	}
 
 	txt += fmt.Sprintf("  - Total: %d\n", s.Total)
@@ -76,12 +250,117 @@ func (s Stats) String() (txt string) {
 	for user, counter := range s.PerUser {
 		txt += fmt.Sprintf("  - %s:\n", user.GetEmail())
 
+		lines := s.PerUserLines[user.GetEmail()]
+
 		for language, n := range counter.PerLanguage() {
-			txt += fmt.Sprintf("    - %s: %d\n", language.Name(), n)
+			txt += fmt.Sprintf("    - %s: %d%s\n", language.Name(), n, lines.describe(language))
 		}
 
 		txt += fmt.Sprintf("    - Total: %d\n", counter.Total())
 	}
 
+	if len(s.PerGroup) != 0 {
+		txt += s.PerGroup.String()
+	}
+
+	if len(s.Projects) != 0 {
+		txt += s.Projects.String()
+	}
+
+	if len(s.Excluded) != 0 {
+		txt += s.Excluded.String()
+	}
+
+	if s.Commits != nil {
+		txt += s.Commits.String()
+	}
+
+	if s.MergeRequests != nil {
+		txt += s.MergeRequests.String()
+	}
+
+	if len(s.Failures) != 0 {
+		txt += s.Failures.String()
+	}
+
+	return
+}
+
+func (s StatsPerGroup) String() (txt string) {
+	txt += "Groups:\n"
+
+	for group, stats := range s {
+		txt += fmt.Sprintf("  - %s:\n", group)
+
+		for k, v := range stats.PerLang {
+			txt += fmt.Sprintf("    - %s: %d\n", k.Name(), v)
+		}
+
+		txt += fmt.Sprintf("    - Total: %d\n", stats.Total)
+
+		for user, counter := range stats.PerUser {
+			txt += fmt.Sprintf("    - %s: %d\n", user.GetEmail(), counter.Total())
+		}
+	}
+
+	return
+}
+
+func (s StatsPerKind) String() (txt string) {
+	txt += "Excluded:\n"
+
+	for kind, stats := range s {
+		txt += fmt.Sprintf("  - %s:\n", kind)
+
+		for k, v := range stats.PerLang {
+			txt += fmt.Sprintf("    - %s: %d\n", k.Name(), v)
+		}
+
+		txt += fmt.Sprintf("    - Total: %d\n", stats.Total)
+	}
+
+	return
+}
+
+// String lists projects with the most lines first and their users with the most lines first.
+func (s StatsPerProject) String() (txt string) {
+	txt += "Projects:\n"
+
+	projects := slices.Collect(maps.Keys(s))
+
+	slices.SortFunc(projects, func(a, b string) int {
+		if c := cmp.Compare(s[b].Total, s[a].Total); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a, b)
+	})
+
+	for _, project := range projects {
+		stats := s[project]
+
+		txt += fmt.Sprintf("  - %s:\n", project)
+
+		for k, v := range stats.PerLang {
+			txt += fmt.Sprintf("    - %s: %d\n", k.Name(), v)
+		}
+
+		txt += fmt.Sprintf("    - Total: %d\n", stats.Total)
+
+		users := slices.Collect(maps.Keys(stats.PerUser))
+
+		slices.SortFunc(users, func(a, b types.User) int {
+			if c := cmp.Compare(stats.PerUser[b].Total(), stats.PerUser[a].Total()); c != 0 {
+				return c
+			}
+
+			return strings.Compare(a.GetEmail(), b.GetEmail())
+		})
+
+		for _, user := range users {
+			txt += fmt.Sprintf("    - %s: %d\n", user.GetEmail(), stats.PerUser[user].Total())
+		}
+	}
+
 	return
 }
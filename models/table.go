@@ -0,0 +1,187 @@
+package models
+
+import (
+	"encoding/csv"
+	"github.com/gaarutyunov/gitstat/types"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// tableHeader are the columns of the long table written by Stats.WriteCSV.
+var tableHeader = []string{"user", "language", "repository", "metric", "value"}
+
+// tableWriter writes rows of the long table, empty columns stand for all values.
+type tableWriter struct {
+	*csv.Writer
+}
+
+func newTableWriter(w io.Writer, comma rune) *tableWriter {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	return &tableWriter{cw}
+}
+
+func (t *tableWriter) row(user, language, repository, metric string, value int) error {
+	return t.Write([]string{user, language, repository, metric, strconv.Itoa(value)})
+}
+
+// WriteCSV writes the statistics as a long table with one row per user, language, repository and metric
+// and fields separated by comma, which is ',' for csv and '\t' for tsv.
+// Lines are per project if the backend counts them, repository is empty otherwise.
+func (s Stats) WriteCSV(w io.Writer, comma rune) error {
+	t := newTableWriter(w, comma)
+
+	if err := t.Write(tableHeader); err != nil {
+		return err
+	}
+
+	if len(s.Projects) == 0 {
+		if err := t.lines("", &s.LineStats, s.Excluded); err != nil {
+			return err
+		}
+	}
+
+	for _, project := range slices.Sorted(maps.Keys(s.Projects)) {
+		stats := s.Projects[project]
+
+		if err := t.lines(project, &stats.LineStats, stats.Excluded); err != nil {
+			return err
+		}
+	}
+
+	if s.Commits != nil {
+		if err := t.commits(s.Commits); err != nil {
+			return err
+		}
+	}
+
+	if s.MergeRequests != nil {
+		if err := t.mergeRequests(s.MergeRequests); err != nil {
+			return err
+		}
+	}
+
+	t.Flush()
+
+	return t.Error()
+}
+
+func (t *tableWriter) lines(repository string, stats *LineStats, excluded StatsPerKind) error {
+	for _, user := range sortedUsers(stats.PerUser) {
+		email := user.GetEmail()
+		lines := stats.PerUserLines[email]
+		perLang := stats.PerUser[user].PerLanguage()
+
+		for _, language := range sortedLanguages(perLang) {
+			if err := t.row(email, language.Name(), repository, "code_lines", perLang[language]); err != nil {
+				return err
+			}
+
+			if lines == nil {
+				continue
+			}
+
+			for _, kind := range []types.LineKind{types.Comment, types.Blank} {
+				if err := t.row(email, language.Name(), repository, string(kind)+"_lines", lines[kind][language]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, kind := range slices.Sorted(maps.Keys(excluded)) {
+		perLang := excluded[kind].PerLang
+
+		for _, language := range sortedLanguages(perLang) {
+			if err := t.row("", language.Name(), repository, string(kind)+"_lines", perLang[language]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// commits writes commits per user and language and per repository and language,
+// the counters don't break users down by repository.
+func (t *tableWriter) commits(s *CommitStats) error {
+	write := func(user, repository string, counter types.PerLanguageCommitCounter) error {
+		perLang := counter.PerLanguage()
+
+		for _, language := range sortedLanguages(perLang) {
+			n := perLang[language]
+
+			for _, metric := range []struct {
+				name  string
+				value int
+			}{
+				{"commits", n.Commits},
+				{"additions", n.Additions},
+				{"deletions", n.Deletions},
+			} {
+				if err := t.row(user, language.Name(), repository, metric.name, metric.value); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	for _, user := range sortedUsers(s.PerUser) {
+		if err := write(user.GetEmail(), "", s.PerUser[user]); err != nil {
+			return err
+		}
+	}
+
+	for _, repository := range slices.Sorted(maps.Keys(s.PerRepo)) {
+		if err := write("", repository, s.PerRepo[repository]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *tableWriter) mergeRequests(s *MergeRequestStats) error {
+	for _, user := range sortedUsers(s.PerUser) {
+		n := newMergeRequestCountJSON(s.PerUser[user])
+
+		for _, metric := range []struct {
+			name  string
+			value string
+		}{
+			{"merge_requests_opened", strconv.Itoa(n.Opened)},
+			{"merge_requests_merged", strconv.Itoa(n.Merged)},
+			{"merge_requests_closed", strconv.Itoa(n.Closed)},
+			{"merge_requests_approvals", strconv.Itoa(n.Approvals)},
+			{"merge_requests_comments", strconv.Itoa(n.Comments)},
+			{"merge_requests_median_time_to_merge_seconds", strconv.FormatFloat(n.MedianTimeToMergeSeconds, 'f', -1, 64)},
+			{"merge_requests_p90_time_to_merge_seconds", strconv.FormatFloat(n.P90TimeToMergeSeconds, 'f', -1, 64)},
+		} {
+			if err := t.Write([]string{user.GetEmail(), "", "", metric.name, metric.value}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// sortedUsers returns users of m sorted by email.
+func sortedUsers[V any](m map[types.User]V) []types.User {
+	return slices.SortedFunc(maps.Keys(m), func(a, b types.User) int {
+		return strings.Compare(a.GetEmail(), b.GetEmail())
+	})
+}
+
+// sortedLanguages returns languages of m sorted by name.
+func sortedLanguages[V any](m map[types.Language]V) []types.Language {
+	return slices.SortedFunc(maps.Keys(m), func(a, b types.Language) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+}
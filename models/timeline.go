@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// Timeline is a series of statistics snapshots keyed by date in 2006-01-02 format.
+type Timeline map[string]*Stats
+
+func (t Timeline) dates() []string {
+	dates := make([]string, 0, len(t))
+
+	for date := range t {
+		dates = append(dates, date)
+	}
+
+	slices.Sort(dates)
+
+	return dates
+}
+
+// WriteCSV writes lines per date, user and language with a header row and fields separated by comma,
+// which is ',' for csv and '\t' for tsv. Rows are sorted by date, user email and language name.
+func (t Timeline) WriteCSV(w io.Writer, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	if err := cw.Write([]string{"date", "user", "language", "lines"}); err != nil {
+		return err
+	}
+
+	for _, date := range t.dates() {
+		perUser := t[date].PerUser
+
+		for _, user := range sortedUsers(perUser) {
+			perLang := perUser[user].PerLanguage()
+
+			for _, language := range sortedLanguages(perLang) {
+				if err := cw.Write([]string{date, user.GetEmail(), language.Name(), strconv.Itoa(perLang[language])}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func (t Timeline) String() (txt string) {
+	for _, date := range t.dates() {
+		txt += fmt.Sprintf("%s:\n%s\n", date, t[date].String())
+	}
+
+	return
+}
@@ -0,0 +1,181 @@
+package owners
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+type (
+	// Rule is a CODEOWNERS line assigning owners to files matching the pattern.
+	Rule struct {
+		Pattern string   `json:"pattern"`
+		Owners  []string `json:"owners"`
+	}
+
+	// File is a CODEOWNERS file, the last matching rule wins.
+	File struct {
+		Rules []Rule `json:"rules"`
+	}
+
+	// Change is a rule added, removed or assigned to other owners.
+	Change struct {
+		Pattern string   `json:"pattern"`
+		Old     []string `json:"old,omitempty"`
+		New     []string `json:"new,omitempty"`
+	}
+)
+
+// Locations are paths CODEOWNERS files are looked up at by GitHub and GitLab in order.
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// Parse reads rules of a CODEOWNERS file skipping comments and GitLab section headers.
+func Parse(data []byte) *File {
+	f := &File{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if i := strings.Index(line, " #"); i != -1 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		f.Rules = append(f.Rules, Rule{Pattern: fields[0], Owners: fields[1:]})
+	}
+
+	return f
+}
+
+// Suggest returns rules assigning every directory of paths to at most top owners having at least minShare of its lines.
+// Directories owned like their parent are left out.
+func Suggest(paths []Path, top int, minShare float64) *File {
+	f := &File{}
+	owned := make(map[string][]string)
+
+	for _, p := range paths {
+		if !p.IsDir() {
+			continue
+		}
+
+		var names []string
+
+		for _, owner := range p.Top(top) {
+			if name := owner.Name(); name != "" && owner.Share >= minShare {
+				names = append(names, name)
+			}
+		}
+
+		parent := parentDir(p.Path)
+
+		if len(names) == 0 || slices.Equal(names, owned[parent]) {
+			owned[p.Path] = owned[parent]
+			continue
+		}
+
+		owned[p.Path] = names
+
+		pattern := p.Path
+		if pattern == "/" {
+			pattern = "*"
+		}
+
+		f.Rules = append(f.Rules, Rule{Pattern: pattern, Owners: names})
+	}
+
+	return f
+}
+
+// Diff returns changes turning f into other by pattern, owners are compared ignoring order and case.
+func (f *File) Diff(other *File) (changes []Change) {
+	old, updated := f.owners(), other.owners()
+
+	patterns := slices.Sorted(maps.Keys(old))
+
+	for pattern := range updated {
+		if _, ok := old[pattern]; !ok {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	slices.Sort(patterns)
+
+	for _, pattern := range patterns {
+		if sameOwners(old[pattern], updated[pattern]) {
+			continue
+		}
+
+		changes = append(changes, Change{Pattern: pattern, Old: old[pattern], New: updated[pattern]})
+	}
+
+	return
+}
+
+func (f *File) owners() map[string][]string {
+	res := make(map[string][]string, len(f.Rules))
+
+	for _, rule := range f.Rules {
+		res[rule.Pattern] = rule.Owners
+	}
+
+	return res
+}
+
+func (f *File) String() (txt string) {
+	for _, rule := range f.Rules {
+		txt += fmt.Sprintf("%s %s\n", rule.Pattern, strings.Join(rule.Owners, " "))
+	}
+
+	return
+}
+
+func (c Change) String() string {
+	switch {
+	case c.Old == nil:
+		return fmt.Sprintf("+ %s %s", c.Pattern, strings.Join(c.New, " "))
+	case c.New == nil:
+		return fmt.Sprintf("- %s %s", c.Pattern, strings.Join(c.Old, " "))
+	default:
+		return fmt.Sprintf("~ %s %s (was %s)", c.Pattern, strings.Join(c.New, " "), strings.Join(c.Old, " "))
+	}
+}
+
+func sameOwners(a, b []string) bool {
+	if len(a) != len(b) || (a == nil) != (b == nil) {
+		return false
+	}
+
+	normalize := func(owners []string) []string {
+		res := make([]string, len(owners))
+
+		for i, owner := range owners {
+			res[i] = strings.ToLower(owner)
+		}
+
+		slices.Sort(res)
+
+		return res
+	}
+
+	return slices.Equal(normalize(a), normalize(b))
+}
+
+// parentDir returns the parent of the directory path ending with a slash.
+func parentDir(path string) string {
+	if path == "/" {
+		return ""
+	}
+
+	i := strings.LastIndex(strings.TrimSuffix(path, "/"), "/")
+
+	return path[:i+1]
+}
@@ -0,0 +1,185 @@
+package owners
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/types"
+	"maps"
+	"slices"
+	"strings"
+)
+
+type (
+	// Owner is a user with the number and share of code lines last changed by them.
+	Owner struct {
+		User  types.User `json:"-"`
+		Email string     `json:"email"`
+		Lines int        `json:"lines"`
+		Share float64    `json:"share"`
+	}
+
+	// Path is a directory ending with a slash or a file with its owners, most lines first.
+	Path struct {
+		Path   string  `json:"path"`
+		Lines  int     `json:"lines"`
+		Owners []Owner `json:"owners"`
+	}
+)
+
+// Aggregate sums lines of files per user into every directory up to depth below the root "/",
+// files are listed as well if files is set.
+func Aggregate(perFile map[string]map[types.User]int, depth int, files bool) []Path {
+	perPath := make(map[string]map[types.User]int)
+
+	add := func(path string, users map[types.User]int) {
+		if perPath[path] == nil {
+			perPath[path] = make(map[types.User]int)
+		}
+
+		for user, n := range users {
+			perPath[path][user] += n
+		}
+	}
+
+	for file, users := range perFile {
+		dirs := strings.Split(file, "/")
+		dirs = dirs[:len(dirs)-1]
+
+		prefix := "/"
+		add(prefix, users)
+
+		for i, dir := range dirs {
+			if i == depth {
+				break
+			}
+
+			prefix += dir + "/"
+			add(prefix, users)
+		}
+
+		if files {
+			add("/"+file, users)
+		}
+	}
+
+	res := make([]Path, 0, len(perPath))
+
+	for _, path := range slices.Sorted(maps.Keys(perPath)) {
+		res = append(res, newPath(path, perPath[path]))
+	}
+
+	return res
+}
+
+func newPath(path string, users map[types.User]int) Path {
+	p := Path{Path: path}
+
+	for _, n := range users {
+		p.Lines += n
+	}
+
+	for user, n := range users {
+		p.Owners = append(p.Owners, Owner{
+			User:  user,
+			Email: user.GetEmail(),
+			Lines: n,
+			Share: float64(n) / float64(p.Lines),
+		})
+	}
+
+	slices.SortFunc(p.Owners, func(a, b Owner) int {
+		if c := cmp.Compare(b.Lines, a.Lines); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Email, b.Email)
+	})
+
+	return p
+}
+
+// Top returns at most n owners of p.
+func (p Path) Top(n int) []Owner {
+	if n <= 0 || len(p.Owners) <= n {
+		return p.Owners
+	}
+
+	return p.Owners[:n]
+}
+
+// IsDir reports whether p is a directory.
+func (p Path) IsDir() bool {
+	return strings.HasSuffix(p.Path, "/")
+}
+
+// Name returns how the owner is mentioned in CODEOWNERS, by username if known and by email otherwise,
+// an empty name means the owner is unknown.
+func (o Owner) Name() string {
+	if o.User == models.DefaultUser {
+		return ""
+	}
+
+	if named, ok := o.User.(types.NamedUser); ok && named.GetUsername() != "" {
+		return "@" + named.GetUsername()
+	}
+
+	return o.Email
+}
+
+type (
+	// Report is the ownership of a project with its suggested CODEOWNERS file and changes to the existing one.
+	Report struct {
+		Paths      []Path   `json:"paths"`
+		Codeowners *File    `json:"codeowners,omitempty"`
+		Existing   string   `json:"existing,omitempty"`
+		Changes    []Change `json:"changes,omitempty"`
+	}
+
+	// Reports are reports of projects by their path.
+	Reports map[string]*Report
+)
+
+func (r Reports) String() (txt string) {
+	for _, project := range slices.Sorted(maps.Keys(r)) {
+		report := r[project]
+
+		txt += fmt.Sprintf("%s:\n", project)
+
+		for _, p := range report.Paths {
+			owners := make([]string, 0, len(p.Owners))
+
+			for _, owner := range p.Owners {
+				owners = append(owners, fmt.Sprintf("%s %.1f%% (%d)", owner.Email, owner.Share*100, owner.Lines))
+			}
+
+			txt += fmt.Sprintf("  %s: %s\n", p.Path, strings.Join(owners, ", "))
+		}
+
+		if report.Codeowners != nil {
+			txt += "  Suggested CODEOWNERS:\n"
+
+			for _, line := range strings.Split(strings.TrimSuffix(report.Codeowners.String(), "\n"), "\n") {
+				txt += fmt.Sprintf("    %s\n", line)
+			}
+		}
+
+		if report.Changes != nil {
+			if report.Existing == "" {
+				txt += "  No CODEOWNERS found, changes to an empty one:\n"
+			} else {
+				txt += fmt.Sprintf("  Changes to %s:\n", report.Existing)
+			}
+
+			for _, change := range report.Changes {
+				txt += fmt.Sprintf("    %s\n", change)
+			}
+
+			if len(report.Changes) == 0 {
+				txt += "    no changes\n"
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,180 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"strings"
+)
+
+const (
+	// chartItems is the number of items charts show, the rest is summed up as other.
+	chartItems = 10
+	// otherColor is the color of other items.
+	otherColor = "#bab0ac"
+)
+
+// palette are colors of languages in the order of the report.
+var palette = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+	"#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#86bcb6",
+}
+
+// slice is an item of a chart.
+type slice struct {
+	label string
+	value int
+	color string
+}
+
+// colors returns colors of languages by name, languages without a color of their own share otherColor.
+func (r *Report) colors() map[string]string {
+	colors := make(map[string]string, len(r.Languages))
+
+	for i, language := range r.Languages {
+		if i < len(palette) {
+			colors[language.Name] = palette[i]
+		} else {
+			colors[language.Name] = otherColor
+		}
+	}
+
+	return colors
+}
+
+// languageSlices returns the first chartItems languages and the sum of the rest.
+func languageSlices(languages []Language, colors map[string]string) []slice {
+	res := make([]slice, 0, min(len(languages), chartItems))
+	other := 0
+
+	for i, language := range languages {
+		if i < chartItems-1 || len(languages) == chartItems {
+			res = append(res, slice{language.Name, language.Lines, colors[language.Name]})
+		} else {
+			other += language.Lines
+		}
+	}
+
+	if other != 0 {
+		res = append(res, slice{"Other", other, otherColor})
+	}
+
+	return res
+}
+
+// barChart returns a horizontal bar chart of the lines of users with the most lines.
+func barChart(users []User) template.HTML {
+	const (
+		width, labelWidth, valueWidth, rowHeight = 640, 220, 80, 24
+		barWidth                                 = width - labelWidth - valueWidth
+	)
+
+	if len(users) > chartItems {
+		users = users[:chartItems]
+	}
+
+	if len(users) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`, width, len(users)*rowHeight)
+
+	for i, user := range users {
+		y := i * rowHeight
+		w := float64(barWidth) * float64(user.Lines) / float64(max(users[0].Lines, 1))
+
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end">%s</text>`, labelWidth-8, y+16, html.EscapeString(user.Email))
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"><title>%s: %d</title></rect>`,
+			labelWidth, y+4, w, rowHeight-8, palette[0], html.EscapeString(user.Email), user.Lines)
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%d">%s</text>`, float64(labelWidth)+w+6, y+16, formatNumber(user.Lines))
+	}
+
+	sb.WriteString(`</svg>`)
+
+	return template.HTML(sb.String())
+}
+
+// pieChart returns a pie chart of lines per language with a legend.
+func pieChart(languages []Language, colors map[string]string) template.HTML {
+	const radius, legendX, rowHeight = 90, 220, 20
+
+	items := languageSlices(languages, colors)
+
+	total := 0
+
+	for _, s := range items {
+		total += s.value
+	}
+
+	if total == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="480" height="%d" font-family="sans-serif" font-size="12">`,
+		max(2*radius+20, len(items)*rowHeight+10))
+
+	// slices are strokes of a circle with the radius of half of the pie as wide as the pie, dashed to the slice length
+	cx, cy := radius+10, radius+10
+	circumference := math.Pi * radius
+	offset := 0.0
+
+	for i, s := range items {
+		length := circumference * float64(s.value) / float64(total)
+
+		fmt.Fprintf(&sb, `<circle cx="%d" cy="%d" r="%g" fill="none" stroke="%s" stroke-width="%d" stroke-dasharray="%.3f %.3f" stroke-dashoffset="%.3f" transform="rotate(-90 %d %d)"><title>%s: %s</title></circle>`,
+			cx, cy, radius/2.0, s.color, radius, length, circumference, -offset, cx, cy,
+			html.EscapeString(s.label), formatPercent(share(s.value, total)))
+
+		offset += length
+
+		y := 10 + i*rowHeight
+
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/>`, legendX, y, s.color)
+		fmt.Fprintf(&sb, `<text x="%d" y="%d">%s %s</text>`, legendX+18, y+10, html.EscapeString(s.label), formatPercent(share(s.value, total)))
+	}
+
+	sb.WriteString(`</svg>`)
+
+	return template.HTML(sb.String())
+}
+
+// mixChart returns a stacked bar of the share of languages of a user.
+func mixChart(languages []Language, colors map[string]string) template.HTML {
+	const width, height = 240, 14
+
+	items := languageSlices(languages, colors)
+
+	total := 0
+
+	for _, s := range items {
+		total += s.value
+	}
+
+	if total == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`, width, height)
+
+	x := 0.0
+
+	for _, s := range items {
+		w := width * float64(s.value) / float64(total)
+
+		fmt.Fprintf(&sb, `<rect x="%.2f" y="0" width="%.2f" height="%d" fill="%s"><title>%s: %s</title></rect>`,
+			x, w, height, s.color, html.EscapeString(s.label), formatPercent(share(s.value, total)))
+
+		x += w
+	}
+
+	sb.WriteString(`</svg>`)
+
+	return template.HTML(sb.String())
+}
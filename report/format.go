@@ -0,0 +1,121 @@
+package report
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// mixLanguages is the number of languages named in the language mix of a user.
+const mixLanguages = 3
+
+// markdownEscaper escapes characters with a meaning in markdown tables and inline formatting.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, `|`, `\|`, `*`, `\*`, `_`, `\_`, "`", "\\`", `[`, `\[`, `]`, `\]`, `<`, `&lt;`, `>`, `&gt;`,
+)
+
+// funcs returns functions available to templates rendering r.
+func (r *Report) funcs() map[string]any {
+	colors := r.colors()
+
+	return map[string]any{
+		"inc": func(i int) int {
+			return i + 1
+		},
+		"number":   formatNumber,
+		"percent":  formatPercent,
+		"duration": formatDuration,
+		"mix":      formatMix,
+		"markdown": markdownEscaper.Replace,
+		"sortBy":   sortBy,
+		"top":      top,
+		"percentOf": func(n, total int) string {
+			return formatPercent(share(n, total))
+		},
+		"barChart": barChart,
+		"pieChart": func(languages []Language) htmltemplate.HTML {
+			return pieChart(languages, colors)
+		},
+		"mixChart": func(languages []Language) htmltemplate.HTML {
+			return mixChart(languages, colors)
+		},
+	}
+}
+
+// WriteMarkdown writes r as markdown tables.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	t, err := template.New("markdown.tmpl").Funcs(r.funcs()).ParseFS(templates, "templates/markdown.tmpl")
+	if err != nil {
+		return err
+	}
+
+	return t.Execute(w, r)
+}
+
+// WriteHTML writes r as a self-contained HTML page with inline SVG charts.
+func (r *Report) WriteHTML(w io.Writer) error {
+	t, err := htmltemplate.New("html.tmpl").Funcs(r.funcs()).ParseFS(templates, "templates/html.tmpl")
+	if err != nil {
+		return err
+	}
+
+	return t.Execute(w, r)
+}
+
+// formatNumber formats n with thousands separated by commas.
+func formatNumber(n int) string {
+	s := strconv.Itoa(n)
+	sign := ""
+
+	if n < 0 {
+		sign, s = "-", s[1:]
+	}
+
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+
+	return sign + s
+}
+
+// formatPercent formats share from 0 to 1 as a percentage with one decimal.
+func formatPercent(share float64) string {
+	return fmt.Sprintf("%.1f%%", share*100)
+}
+
+// formatDuration formats d rounded to minutes, zero durations are formatted as a dash.
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+
+	return d.Round(time.Minute).String()
+}
+
+// formatMix formats shares of the first mixLanguages languages and the share of the rest.
+func formatMix(languages []Language) string {
+	parts := make([]string, 0, mixLanguages+1)
+	other := 0.0
+
+	for i, language := range languages {
+		if i < mixLanguages {
+			parts = append(parts, fmt.Sprintf("%s %s", language.Name, formatPercent(language.Share)))
+		} else {
+			other += language.Share
+		}
+	}
+
+	if other != 0 {
+		parts = append(parts, fmt.Sprintf("other %s", formatPercent(other)))
+	}
+
+	return strings.Join(parts, ", ")
+}
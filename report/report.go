@@ -0,0 +1,211 @@
+package report
+
+import (
+	"cmp"
+	"github.com/gaarutyunov/gitstat/models"
+	"github.com/gaarutyunov/gitstat/types"
+	"slices"
+	"strings"
+	"time"
+)
+
+type (
+	// Report is a sorted view of models.Stats for rendering, items with the most lines come first
+	// and shares are fractions of the enclosing total.
+	Report struct {
+		Run           Run
+		Total         int
+		Languages     []Language
+		Users         []User
+		Projects      []Project
+		Excluded      []Excluded
+		Commits       []Commits
+		MergeRequests []MergeRequests
+		Failures      models.FailureReport
+	}
+
+	// Run is the metadata of the run computing the statistics, zero values stand for unset flags.
+	Run struct {
+		Started  time.Time
+		Finished time.Time
+		Duration time.Duration
+		Server   string
+		Host     string
+		Query    string
+		Since    time.Time
+		Until    time.Time
+	}
+
+	// Language is the number of code, comment and blank lines in a language.
+	Language struct {
+		Name    string
+		Lines   int
+		Comment int
+		Blank   int
+		Share   float64
+	}
+
+	// User is the number of code lines last changed by a user and their language mix.
+	User struct {
+		Email     string
+		Lines     int
+		Share     float64
+		Languages []Language
+	}
+
+	// Project is the number of code lines of a project at its head commit.
+	Project struct {
+		Name      string
+		Head      string
+		Lines     int
+		Share     float64
+		Languages []Language
+		Users     []User
+	}
+
+	// Excluded is the number of lines of generated or vendored files.
+	Excluded struct {
+		Kind      string
+		Lines     int
+		Languages []Language
+	}
+
+	// Commits is the number of commits, added and deleted lines of a user.
+	Commits struct {
+		Email string
+		types.CommitCount
+	}
+
+	// MergeRequests is the number of merge requests of a user.
+	MergeRequests struct {
+		Email string
+		types.MergeRequestCount
+	}
+)
+
+// New returns the report of stats computed by run.
+func New(stats *models.Stats, run Run) *Report {
+	r := &Report{
+		Run:       run,
+		Total:     stats.Total,
+		Languages: newLanguages(stats.PerLang, stats.Lines, stats.Total),
+		Users:     newUsers(&stats.LineStats),
+		Failures:  stats.Failures,
+	}
+
+	for name, project := range stats.Projects {
+		r.Projects = append(r.Projects, Project{
+			Name:      name,
+			Head:      project.Head,
+			Lines:     project.Total,
+			Share:     share(project.Total, stats.Total),
+			Languages: newLanguages(project.PerLang, project.Lines, project.Total),
+			Users:     newUsers(&project.LineStats),
+		})
+	}
+
+	slices.SortFunc(r.Projects, func(a, b Project) int {
+		return compare(a.Lines, b.Lines, a.Name, b.Name)
+	})
+
+	for kind, excluded := range stats.Excluded {
+		r.Excluded = append(r.Excluded, Excluded{
+			Kind:      string(kind),
+			Lines:     excluded.Total,
+			Languages: newLanguages(excluded.PerLang, nil, excluded.Total),
+		})
+	}
+
+	slices.SortFunc(r.Excluded, func(a, b Excluded) int {
+		return compare(a.Lines, b.Lines, a.Kind, b.Kind)
+	})
+
+	if stats.Commits != nil {
+		for user, counter := range stats.Commits.PerUser {
+			r.Commits = append(r.Commits, Commits{Email: user.GetEmail(), CommitCount: counter.Total()})
+		}
+
+		slices.SortFunc(r.Commits, func(a, b Commits) int {
+			return compare(a.Commits, b.Commits, a.Email, b.Email)
+		})
+	}
+
+	if stats.MergeRequests != nil {
+		for user, n := range stats.MergeRequests.PerUser {
+			r.MergeRequests = append(r.MergeRequests, MergeRequests{Email: user.GetEmail(), MergeRequestCount: n})
+		}
+
+		slices.SortFunc(r.MergeRequests, func(a, b MergeRequests) int {
+			return compare(a.Opened, b.Opened, a.Email, b.Email)
+		})
+	}
+
+	return r
+}
+
+func newLanguages(perLang models.PerLangMap, lines models.LinesPerKind, total int) []Language {
+	res := make([]Language, 0, len(perLang))
+
+	for language, n := range perLang {
+		res = append(res, Language{
+			Name:    language.Name(),
+			Lines:   n,
+			Comment: lines[types.Comment][language],
+			Blank:   lines[types.Blank][language],
+			Share:   share(n, total),
+		})
+	}
+
+	slices.SortFunc(res, func(a, b Language) int {
+		return compare(a.Lines, b.Lines, a.Name, b.Name)
+	})
+
+	return res
+}
+
+func newUsers(stats *models.LineStats) []User {
+	res := make([]User, 0, len(stats.PerUser))
+
+	for user, counter := range stats.PerUser {
+		email := user.GetEmail()
+
+		res = append(res, User{
+			Email:     email,
+			Lines:     counter.Total(),
+			Share:     share(counter.Total(), stats.Total),
+			Languages: newLanguages(counter.PerLanguage(), stats.PerUserLines[email], counter.Total()),
+		})
+	}
+
+	slices.SortFunc(res, func(a, b User) int {
+		return compare(a.Lines, b.Lines, a.Email, b.Email)
+	})
+
+	return res
+}
+
+// compare orders items by a and b descending and then by their names.
+func compare(a, b int, aName, bName string) int {
+	if c := cmp.Compare(b, a); c != 0 {
+		return c
+	}
+
+	return strings.Compare(aName, bName)
+}
+
+func share(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(n) / float64(total)
+}
+
+// TopUsers returns at most n users with the most lines.
+func (r *Report) TopUsers(n int) []User {
+	if n <= 0 || len(r.Users) <= n {
+		return r.Users
+	}
+
+	return r.Users[:n]
+}
@@ -0,0 +1,119 @@
+package report
+
+import (
+	"cmp"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// WriteTemplate renders r with the template at path, html/template escaping is used
+// if the template file has the extension .html or .htm and text/template otherwise.
+func (r *Report) WriteTemplate(w io.Writer, path string) error {
+	name := filepath.Base(path)
+
+	if ext := filepath.Ext(name); ext == ".html" || ext == ".htm" {
+		t, err := htmltemplate.New(name).Funcs(r.funcs()).ParseFiles(path)
+		if err != nil {
+			return err
+		}
+
+		return t.Execute(w, r)
+	}
+
+	t, err := template.New(name).Funcs(r.funcs()).ParseFiles(path)
+	if err != nil {
+		return err
+	}
+
+	return t.Execute(w, r)
+}
+
+// sortBy returns a copy of the slice items sorted by field of its elements in ascending order,
+// or in descending order if field starts with a minus.
+func sortBy(field string, items any) (any, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("%T is not a slice", items)
+	}
+
+	field, desc := strings.CutPrefix(field, "-")
+
+	keys := make([]reflect.Value, v.Len())
+	order := make([]int, v.Len())
+
+	for i := range keys {
+		elem := reflect.Indirect(v.Index(i))
+		if elem.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s is not a struct", elem.Type())
+		}
+
+		keys[i] = elem.FieldByName(field)
+		if !keys[i].IsValid() {
+			return nil, fmt.Errorf("%s has no field %s", elem.Type(), field)
+		}
+
+		order[i] = i
+	}
+
+	var err error
+
+	sort.SliceStable(order, func(i, j int) bool {
+		c, cmpErr := compareValues(keys[order[i]], keys[order[j]])
+		if cmpErr != nil {
+			err = cmpErr
+		}
+
+		if desc {
+			return c > 0
+		}
+
+		return c < 0
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+
+	for i, j := range order {
+		res.Index(i).Set(v.Index(j))
+	}
+
+	return res.Interface(), nil
+}
+
+func compareValues(a, b reflect.Value) (int, error) {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(a.Uint(), b.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float()), nil
+	case reflect.String:
+		return strings.Compare(a.String(), b.String()), nil
+	default:
+		return 0, fmt.Errorf("can't compare values of %s", a.Type())
+	}
+}
+
+// top returns at most the first n elements of the slice items.
+func top(n int, items any) (any, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("%T is not a slice", items)
+	}
+
+	if n < 0 || v.Len() <= n {
+		return items, nil
+	}
+
+	return v.Slice(0, n).Interface(), nil
+}
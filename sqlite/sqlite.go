@@ -0,0 +1,248 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/gaarutyunov/gitstat/types"
+	_ "modernc.org/sqlite"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// schema are normalized tables of projects, their files and blame ranges of the files.
+// Dates are in RFC 3339 format, the date of a range is the commit date.
+const schema = `
+CREATE TABLE projects (
+	id   INTEGER PRIMARY KEY,
+	name TEXT    NOT NULL UNIQUE,
+	head TEXT    NOT NULL
+);
+
+CREATE TABLE languages (
+	id   INTEGER PRIMARY KEY,
+	name TEXT    NOT NULL UNIQUE
+);
+
+CREATE TABLE users (
+	id    INTEGER PRIMARY KEY,
+	email TEXT    NOT NULL UNIQUE,
+	name  TEXT    NOT NULL
+);
+
+CREATE TABLE files (
+	id            INTEGER PRIMARY KEY,
+	project_id    INTEGER NOT NULL REFERENCES projects (id),
+	path          TEXT    NOT NULL,
+	language_id   INTEGER NOT NULL REFERENCES languages (id),
+	kind          TEXT    NOT NULL,
+	code_lines    INTEGER NOT NULL,
+	comment_lines INTEGER NOT NULL,
+	blank_lines   INTEGER NOT NULL,
+	UNIQUE (project_id, path)
+);
+
+CREATE TABLE blame_ranges (
+	id            INTEGER PRIMARY KEY,
+	file_id       INTEGER NOT NULL REFERENCES files (id),
+	commit_sha    TEXT    NOT NULL,
+	author_id     INTEGER REFERENCES users (id),
+	committer_id  INTEGER REFERENCES users (id),
+	date          TEXT,
+	lines         INTEGER NOT NULL,
+	code_lines    INTEGER NOT NULL,
+	comment_lines INTEGER NOT NULL,
+	blank_lines   INTEGER NOT NULL
+);
+
+CREATE INDEX blame_ranges_file_id ON blame_ranges (file_id);
+CREATE INDEX blame_ranges_commit_sha ON blame_ranges (commit_sha);
+CREATE INDEX blame_ranges_author_id ON blame_ranges (author_id);
+CREATE INDEX blame_ranges_committer_id ON blame_ranges (committer_id);
+`
+
+// writer inserts facts in a transaction assigning ids to languages and users when they are first seen.
+type writer struct {
+	stmts     map[string]*sql.Stmt
+	languages map[string]int64
+	users     map[string]int64
+	files     int64
+	ranges    int64
+}
+
+var inserts = map[string]string{
+	"projects":     `INSERT INTO projects (id, name, head) VALUES (?, ?, ?)`,
+	"languages":    `INSERT INTO languages (id, name) VALUES (?, ?)`,
+	"users":        `INSERT INTO users (id, email, name) VALUES (?, ?, ?)`,
+	"files":        `INSERT INTO files (id, project_id, path, language_id, kind, code_lines, comment_lines, blank_lines) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+	"blame_ranges": `INSERT INTO blame_ranges (id, file_id, commit_sha, author_id, committer_id, date, lines, code_lines, comment_lines, blank_lines) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+}
+
+// Write creates the database at path with projects, replacing the file if it exists.
+// The database is built in a temporary file next to path, so the previous one is kept on failure.
+func Write(ctx context.Context, path string, projects []types.ProjectFacts) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if err := errors.Join(f.Chmod(0o644), f.Close()); err != nil {
+		return errors.Join(err, os.Remove(f.Name()))
+	}
+
+	if err := write(ctx, f.Name(), projects); err != nil {
+		return errors.Join(err, os.Remove(f.Name()))
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return errors.Join(err, os.Remove(f.Name()))
+	}
+
+	return nil
+}
+
+// write inserts projects into the empty database at path.
+func write(ctx context.Context, path string, projects []types.ProjectFacts) (err error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, db.Close())
+	}()
+
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	w := &writer{
+		stmts:     make(map[string]*sql.Stmt, len(inserts)),
+		languages: make(map[string]int64),
+		users:     make(map[string]int64),
+	}
+
+	for table, query := range inserts {
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		w.stmts[table] = stmt
+	}
+
+	for i, project := range projects {
+		if err := w.project(ctx, int64(i+1), project); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (w *writer) insert(ctx context.Context, table string, args ...any) error {
+	_, err := w.stmts[table].ExecContext(ctx, args...)
+
+	return err
+}
+
+func (w *writer) project(ctx context.Context, id int64, project types.ProjectFacts) error {
+	if err := w.insert(ctx, "projects", id, project.Project, project.Head); err != nil {
+		return err
+	}
+
+	for _, file := range project.Files {
+		language, err := w.language(ctx, file.Language.Name())
+		if err != nil {
+			return err
+		}
+
+		w.files++
+
+		err = w.insert(ctx, "files", w.files, id, file.Path, language, string(file.Kind),
+			file.Lines[types.Code], file.Lines[types.Comment], file.Lines[types.Blank])
+		if err != nil {
+			return err
+		}
+
+		for _, r := range file.Ranges {
+			if err := w.blameRange(ctx, w.files, r); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *writer) blameRange(ctx context.Context, file int64, r types.BlameRange) error {
+	author, err := w.user(ctx, r.Author)
+	if err != nil {
+		return err
+	}
+
+	committer, err := w.user(ctx, r.Committer)
+	if err != nil {
+		return err
+	}
+
+	var date sql.NullString
+
+	if !r.Date.IsZero() {
+		date = sql.NullString{String: r.Date.UTC().Format(time.RFC3339), Valid: true}
+	}
+
+	w.ranges++
+
+	return w.insert(ctx, "blame_ranges", w.ranges, file, r.Commit, author, committer, date,
+		r.Lines[types.Code]+r.Lines[types.Comment]+r.Lines[types.Blank],
+		r.Lines[types.Code], r.Lines[types.Comment], r.Lines[types.Blank])
+}
+
+// language returns the id of the language inserting it if it's new.
+func (w *writer) language(ctx context.Context, name string) (int64, error) {
+	if id, ok := w.languages[name]; ok {
+		return id, nil
+	}
+
+	id := int64(len(w.languages) + 1)
+
+	if err := w.insert(ctx, "languages", id, name); err != nil {
+		return 0, err
+	}
+
+	w.languages[name] = id
+
+	return id, nil
+}
+
+// user returns the id of the person by email inserting it if it's new, unknown emails have a null id.
+func (w *writer) user(ctx context.Context, person types.Person) (sql.NullInt64, error) {
+	if person.Email == "" {
+		return sql.NullInt64{}, nil
+	}
+
+	if id, ok := w.users[person.Email]; ok {
+		return sql.NullInt64{Int64: id, Valid: true}, nil
+	}
+
+	id := int64(len(w.users) + 1)
+
+	if err := w.insert(ctx, "users", id, person.Email, person.Name); err != nil {
+		return sql.NullInt64{}, err
+	}
+
+	w.users[person.Email] = id
+
+	return sql.NullInt64{Int64: id, Valid: true}, nil
+}
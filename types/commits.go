@@ -0,0 +1,23 @@
+package types
+
+type CommitCount struct {
+	Commits   int `json:"commits"`
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
+}
+
+type PerLanguageCommitCounter interface {
+	PerLanguage() map[Language]CommitCount
+	Total() CommitCount
+}
+
+type CommitCounter interface {
+	PerUser() map[User]PerLanguageCommitCounter
+	PerRepository() map[string]PerLanguageCommitCounter
+	PerLanguageCommitCounter
+}
+
+// CommitStats is implemented by statistics that also count commits.
+type CommitStats interface {
+	Commits() CommitCounter
+}
@@ -0,0 +1,41 @@
+package types
+
+import "time"
+
+type (
+	// Person is the author or committer of a commit.
+	Person struct {
+		Name  string
+		Email string
+	}
+
+	// BlameRange is consecutive lines of a file last changed by a commit at its commit date.
+	BlameRange struct {
+		Commit    string
+		Author    Person
+		Committer Person
+		Date      time.Time
+		Lines     map[LineKind]int64
+	}
+
+	// FileFacts are the lines of a file, ranges are known only for blamed files.
+	FileFacts struct {
+		Path     string
+		Language Language
+		Kind     FileKind
+		Lines    map[LineKind]int64
+		Ranges   []BlameRange
+	}
+
+	// ProjectFacts are the files of a project at its head commit.
+	ProjectFacts struct {
+		Project string
+		Head    string
+		Files   []FileFacts
+	}
+)
+
+// FactStats is implemented by statistics keeping the blame of every file they count.
+type FactStats interface {
+	Facts() []ProjectFacts
+}
@@ -0,0 +1,29 @@
+package types
+
+// ErrorClass groups failures by their cause.
+type ErrorClass string
+
+const (
+	NotFound     ErrorClass = "not_found"
+	Forbidden    ErrorClass = "forbidden"
+	RateLimited  ErrorClass = "rate_limited"
+	ClientError  ErrorClass = "client_error"
+	ServerError  ErrorClass = "server_error"
+	Timeout      ErrorClass = "timeout"
+	NetworkError ErrorClass = "network"
+	OtherError   ErrorClass = "other"
+)
+
+// Failure is a project or a file of it that couldn't be processed, so statistics don't cover it.
+type Failure struct {
+	Project string     `json:"project"`
+	Path    string     `json:"path,omitempty"`
+	Status  int        `json:"status,omitempty"`
+	Class   ErrorClass `json:"class"`
+	Error   string     `json:"error"`
+}
+
+// FailureStats is implemented by statistics that report projects and files which failed.
+type FailureStats interface {
+	Failures() []Failure
+}
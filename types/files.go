@@ -0,0 +1,15 @@
+package types
+
+// FileKind tells whether lines of a file are attributed to users or reported separately.
+type FileKind string
+
+const (
+	Source    FileKind = "source"
+	Generated FileKind = "generated"
+	Vendored  FileKind = "vendored"
+)
+
+// ExcludedStats is implemented by statistics reporting lines of generated and vendored files separately.
+type ExcludedStats interface {
+	Excluded() map[FileKind]PerLanguageCounter
+}
@@ -3,6 +3,13 @@ package types
 type Format string
 
 const (
-	Txt  Format = "txt"
-	Json Format = "json"
+	Txt         Format = "txt"
+	Json        Format = "json"
+	Csv         Format = "csv"
+	Tsv         Format = "tsv"
+	Markdown    Format = "markdown"
+	Html        Format = "html"
+	Template    Format = "template"
+	OpenMetrics Format = "openmetrics"
+	Sqlite      Format = "sqlite"
 )
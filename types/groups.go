@@ -0,0 +1,6 @@
+package types
+
+// GroupStats is implemented by statistics broken down per group of projects.
+type GroupStats interface {
+	PerGroup() map[string]LineCounter
+}
@@ -3,4 +3,27 @@ package types
 type Language interface {
 	Name() string
 	Ext() []string
+	// Comments returns the comment syntax, all non-blank lines are code if it is empty.
+	Comments() Comments
 }
+
+// Comments is the syntax of line and block comments of a language,
+// comment markers inside string and rune literals delimited by Strings are ignored.
+type Comments struct {
+	Line    []string
+	Block   [][2]string
+	Strings []Quote
+}
+
+// Quote delimits string or rune literals.
+type Quote struct {
+	Delimiter string
+	// Raw literals have no backslash escapes.
+	Raw bool
+	// Multiline literals may span lines, others end at the end of the line if they aren't closed.
+	Multiline bool
+}
+
+// LanguageResolver resolves the language of the file at path, content reads the file
+// if the path is not enough and is nil when the file contents are not available.
+type LanguageResolver func(path string, content func() ([]byte, error)) (Language, bool)
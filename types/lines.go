@@ -17,3 +17,24 @@ type LineCounter interface {
 	PerUserCounter
 	PerLanguageCounter
 }
+
+// LineKind is the kind of a line in a source file.
+type LineKind string
+
+const (
+	Code    LineKind = "code"
+	Comment LineKind = "comment"
+	Blank   LineKind = "blank"
+)
+
+// PerKindCounter is implemented by counters breaking lines down into code, comment and blank lines.
+// Line counts of other counters are code lines.
+type PerKindCounter interface {
+	PerKind() map[LineKind]map[Language]int
+}
+
+// PerUserKindCounter is implemented by counters breaking lines of every user down by kind,
+// unlike PerUser it includes users with only comment or blank lines.
+type PerUserKindCounter interface {
+	PerUserKind() map[User]PerKindCounter
+}
@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+type MergeRequestCount struct {
+	Opened            int
+	Merged            int
+	Closed            int
+	MedianTimeToMerge time.Duration
+	P90TimeToMerge    time.Duration
+	Approvals         int
+	Comments          int
+}
+
+type MergeRequestCounter interface {
+	PerUser() map[User]MergeRequestCount
+	Total() MergeRequestCount
+}
+
+// MergeRequestStats is implemented by statistics that also count merge requests.
+type MergeRequestStats interface {
+	MergeRequests() MergeRequestCounter
+}
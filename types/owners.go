@@ -0,0 +1,13 @@
+package types
+
+// FileOwnerCounter counts code lines per user of every file of a project.
+type FileOwnerCounter interface {
+	PerFile() map[string]map[User]int
+	// Codeowners returns the path and content of the CODEOWNERS file of the project, an empty path if there is none.
+	Codeowners() (string, []byte)
+}
+
+// OwnerStats is implemented by statistics counting lines per file of every project.
+type OwnerStats interface {
+	Owners() map[string]FileOwnerCounter
+}
@@ -0,0 +1,15 @@
+package types
+
+// ProjectCounter counts lines of a single project at its head commit.
+type ProjectCounter interface {
+	LineCounter
+	PerKindCounter
+	ExcludedStats
+	Head() string
+}
+
+// ProjectStats is implemented by statistics recording lines of every project,
+// which allows updating reports incrementally.
+type ProjectStats interface {
+	PerProject() map[string]ProjectCounter
+}
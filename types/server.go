@@ -5,4 +5,5 @@ type GitServer string
 const (
 	Gitlab GitServer = "gitlab"
 	GitHub GitServer = "github"
+	Local  GitServer = "local"
 )
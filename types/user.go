@@ -4,3 +4,8 @@ type User interface {
 	GetEmail() string
 	GetAliases() []string
 }
+
+// NamedUser is implemented by users of Git servers with usernames they are mentioned by.
+type NamedUser interface {
+	GetUsername() string
+}
@@ -0,0 +1,39 @@
+package utils
+
+import "time"
+
+// Period is a time range with optional bounds, Since is inclusive and Until is exclusive.
+type Period struct {
+	Since time.Time
+	Until time.Time
+}
+
+// IsZero reports whether the period has no bounds.
+func (p Period) IsZero() bool {
+	return p.Since.IsZero() && p.Until.IsZero()
+}
+
+func (p Period) Contains(t time.Time) bool {
+	if !p.Since.IsZero() && t.Before(p.Since) {
+		return false
+	}
+
+	if !p.Until.IsZero() && !t.Before(p.Until) {
+		return false
+	}
+
+	return true
+}
+
+// ParseTime parses a date in 2006-01-02 or RFC 3339 format, an empty string is the zero time.
+func ParseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	// Pool bounds the number of functions running at once in all of its groups,
+	// a nil pool or a pool of size 0 is unbounded.
+	Pool struct {
+		sem chan struct{}
+	}
+
+	// Group runs functions in a pool and waits for them to finish.
+	Group struct {
+		pool *Pool
+		wg   sync.WaitGroup
+	}
+)
+
+func NewPool(size int) *Pool {
+	if size <= 0 {
+		return &Pool{}
+	}
+
+	return &Pool{sem: make(chan struct{}, size)}
+}
+
+func (p *Pool) Group() *Group {
+	return &Group{pool: p}
+}
+
+// Go runs f on a new goroutine blocking until the pool has a free worker,
+// the context error is returned without running f if ctx is done first.
+func (g *Group) Go(ctx context.Context, f func()) error {
+	var sem chan struct{}
+
+	if g.pool != nil {
+		sem = g.pool.sem
+	}
+
+	if sem != nil {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+
+		if sem != nil {
+			defer func() {
+				<-sem
+			}()
+		}
+
+		f()
+	}()
+
+	return nil
+}
+
+// Wait blocks until all functions of the group are finished.
+func (g *Group) Wait() {
+	g.wg.Wait()
+}